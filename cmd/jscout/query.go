@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cyinnove/jscout/pkg/store"
+	"github.com/cyinnove/jscout/utils"
+)
+
+func newQueryCmd() *cobra.Command {
+	var (
+		dbPath     string
+		table      string
+		hosts      []string
+		run        string
+		since      string
+		until      string
+		kinds      []string
+		format     string
+		tmplPath   string
+		outputPath string
+		unique     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Query crawl results stored with --format sqlite",
+		Example: `  jscout query --db jscout.db --host example.com --kind module
+  jscout query --db jscout.db --run latest --since 7d --format jsonl
+  jscout query --db jscout.db --table pages --format csv -o pages.csv`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(dbPath); err != nil {
+				return fmt.Errorf("open database: %w", err)
+			}
+			st, err := store.Open(dbPath)
+			if err != nil {
+				return fmt.Errorf("open database: %w", err)
+			}
+			defer st.Close()
+
+			if _, ok := utils.LookupFormat(format); !ok {
+				return fmt.Errorf("unknown format: %s (use %s)", format, strings.Join(utils.Formats(), "|"))
+			}
+			if strings.EqualFold(format, "template") {
+				if _, err := utils.ParseTemplate(tmplPath); err != nil {
+					return err
+				}
+			}
+
+			f := store.Filter{Hosts: hosts, Kinds: kinds}
+			switch run {
+			case "":
+			case "latest":
+				if f.RunID, err = st.LatestRun(); err != nil {
+					return err
+				}
+				if f.RunID == 0 {
+					return fmt.Errorf("database has no runs")
+				}
+			default:
+				if f.RunID, err = strconv.ParseInt(run, 10, 64); err != nil || f.RunID <= 0 {
+					return fmt.Errorf("invalid --run %q (use a run id or latest)", run)
+				}
+			}
+			if f.FirstSeenSince, err = parseTimeArg(since); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			if f.FirstSeenUntil, err = parseTimeArg(until); err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+
+			return utils.WriteTo(outputPath, func(w io.Writer) error {
+				switch strings.ToLower(table) {
+				case "scripts", "js":
+					recs, err := st.Scripts(f)
+					if err != nil {
+						return err
+					}
+					return utils.Write(w, format, utils.OutputData{Records: recs, Unique: unique, TemplatePath: tmplPath})
+				case "pages":
+					pages, err := st.Pages(f)
+					if err != nil {
+						return err
+					}
+					if strings.EqualFold(format, "html") {
+						return utils.Write(w, format, utils.OutputData{Pages: pages})
+					}
+					return utils.WritePages(w, format, pages)
+				case "endpoints":
+					eps, err := st.Endpoints(f)
+					if err != nil {
+						return err
+					}
+					return writeRows(w, format, []string{"url", "source_page", "first_seen", "last_seen"}, eps,
+						func(e store.Endpoint) []string { return []string{e.URL, e.SourcePage, e.FirstSeen, e.LastSeen} })
+				case "findings":
+					fds, err := st.Findings(f)
+					if err != nil {
+						return err
+					}
+					return writeRows(w, format, []string{"kind", "severity", "url", "script_url", "detail", "first_seen", "last_seen"}, fds,
+						func(fd store.Finding) []string {
+							return []string{fd.Kind, fd.Severity, fd.URL, fd.ScriptURL, fd.Detail, fd.FirstSeen, fd.LastSeen}
+						})
+				case "runs":
+					runs, err := st.Runs()
+					if err != nil {
+						return err
+					}
+					return writeRows(w, format, []string{"id", "started_at", "finished_at", "seeds", "scope"}, runs,
+						func(r store.Run) []string {
+							return []string{strconv.FormatInt(r.ID, 10), r.StartedAt.Format(time.RFC3339), r.FinishedAt.Format(time.RFC3339),
+								strings.Join(r.Seeds, " "), strings.Join(r.Scope, " ")}
+						})
+				default:
+					return fmt.Errorf("unknown table: %s (use scripts|pages|endpoints|findings|runs)", table)
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&dbPath, "db", "jscout.db", "SQLite database written with --format sqlite")
+	cmd.Flags().StringVar(&table, "table", "scripts", "What to list: scripts|pages|endpoints|findings|runs")
+	cmd.Flags().StringSliceVar(&hosts, "host", nil, "Only rows whose host matches these suffixes (can be used multiple times)")
+	cmd.Flags().StringVar(&run, "run", "", "Only rows seen in this run: a run id or latest")
+	cmd.Flags().StringVar(&since, "since", "", "Only rows first seen at or after this time (e.g. 24h, 7d, 2024-01-15, RFC3339)")
+	cmd.Flags().StringVar(&until, "until", "", "Only rows first seen before this time (same syntax as --since)")
+	cmd.Flags().StringSliceVar(&kinds, "kind", nil, "Only scripts of these kinds (javascript|module|commonjs|jsonp|typescript|wasm), or findings of these kinds")
+	cmd.Flags().StringVar(&format, "format", "txt", "Output format from the same registry as crawl output: txt|jsonl|csv|html|template for scripts; txt|jsonl|csv for other tables (and html for pages)")
+	cmd.Flags().StringVar(&tmplPath, "template", "", "Go text/template file rendered once per script by the template format")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "-", "Output path or '-' for STDOUT")
+	cmd.Flags().BoolVar(&unique, "unique", true, "De-duplicate JS URLs in output (txt mode)")
+	return cmd
+}
+
+// parseTimeArg accepts a look-back duration (90m, 24h, 7d) or an absolute
+// date or RFC3339 timestamp. An empty string yields the zero time.
+func parseTimeArg(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		if days, err := strconv.Atoi(n); err == nil {
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a duration nor a date", s)
+}
+
+// writeRows renders non-record tables, which the format registry does not
+// cover: space-separated columns for txt, one JSON object per line for
+// jsonl, or csv with the given header.
+func writeRows[T any](w io.Writer, format string, header []string, rows []T, cols func(T) []string) error {
+	switch strings.ToLower(format) {
+	case "txt", "text":
+		for _, r := range rows {
+			if _, err := fmt.Fprintln(w, strings.Join(cols(r), " ")); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "jsonl", "ndjson":
+		enc := json.NewEncoder(w)
+		for _, r := range rows {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := cw.Write(cols(r)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
@@ -28,22 +28,29 @@ func newRootCmd() *cobra.Command {
 				utils.PrintBanner()
 			}
 
-			// Chrome verification on all platforms
-			p, err := utils.EnsureChromePath(cfg.ChromePath)
-			if err != nil {
-				// Print error and exit without showing help
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+			// Chrome verification on all platforms; a remote browser needs no local Chrome
+			if cfg.RemoteURL == "" {
+				p, err := utils.EnsureChromePath(cfg.ChromePath)
+				if err != nil {
+					// Print error and exit without showing help
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+
+				// Validate that Chrome actually works
+				if err := utils.ValidateChromePath(p); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: chrome validation failed: %v\n", err)
+					os.Exit(1)
+				}
+
+				cfg.ChromePath = p
 			}
 
-			// Validate that Chrome actually works
-			if err := utils.ValidateChromePath(p); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: chrome validation failed: %v\n", err)
-				os.Exit(1)
+			// --output-dir replaces the default STDOUT output unless -o is given too
+			if cfg.OutputDir != "" && !cmd.Flags().Changed("output") {
+				cfg.Outputs = nil
 			}
 
-			cfg.ChromePath = p
-
 			r := runner.New(cfg)
 			if err := r.Run(); err != nil {
 				return err
@@ -66,22 +73,68 @@ func newRootCmd() *cobra.Command {
 	cmd.Flags().IntVar(&cfg.MaxDepth, "max-depth", cfg.MaxDepth, "Max crawl depth from seeds")
 	cmd.Flags().IntVar(&cfg.MaxPages, "max-pages", cfg.MaxPages, "Max pages to visit (0 = unlimited)")
 	cmd.Flags().IntVarP(&cfg.Concurrency, "concurrency", "c", cfg.Concurrency, "Concurrent pages (tabs) to process")
-	cmd.Flags().IntVar(&cfg.WaitSeconds, "wait", cfg.WaitSeconds, "Seconds to wait after load for dynamic scripts")
+	cmd.Flags().IntVar(&cfg.SeedConcurrency, "seed-concurrency", cfg.SeedConcurrency, "Max concurrent pages per seed, so one large site cannot take every tab (0 = no limit)")
+	cmd.Flags().IntVar(&cfg.WaitSeconds, "wait", cfg.WaitSeconds, "Max seconds to wait for --wait-until after load, and for the network to go idle after interactions")
+	cmd.Flags().StringVar(&cfg.WaitUntil, "wait-until", cfg.WaitUntil, "When a page counts as loaded: load|domcontentloaded|networkidle0|networkidle2|selector:<css>|fixed:<dur> (e.g. fixed:2s)")
 	cmd.Flags().IntVar(&cfg.PageTimeoutSec, "page-timeout", cfg.PageTimeoutSec, "Per-page timeout in seconds")
+	cmd.Flags().StringVar(&cfg.Strategy, "strategy", cfg.Strategy, "Crawl order: bfs|dfs|score (score favours app areas, novel URL patterns and JS-rich parents)")
+	cmd.Flags().BoolVar(&cfg.SPARoutes, "spa-routes", cfg.SPARoutes, "Discover client-side routes (pushState, hash routes, React/Vue/Angular routers)")
+
+	cmd.Flags().BoolVar(&cfg.Initiators, "initiators", cfg.Initiators, "Record which script (and stack position) initiated each JS load")
+
+	// Exploration
+	cmd.Flags().BoolVar(&cfg.Explore, "explore", cfg.Explore, "Click buttons, tabs and menus to trigger lazy-loaded JS")
+	cmd.Flags().IntVar(&cfg.ExploreMaxActions, "explore-max-actions", cfg.ExploreMaxActions, "Max clicks per page during exploration")
+	cmd.Flags().StringSliceVar(&cfg.ExploreDeny, "explore-deny", cfg.ExploreDeny, "Extra text or CSS selectors never to click (can be used multiple times)")
+	cmd.Flags().BoolVar(&cfg.ExploreSafe, "explore-safe", cfg.ExploreSafe, "Never click or submit elements matching the built-in deny-lists (logout, delete, pay, submit, ...)")
+	cmd.Flags().BoolVar(&cfg.FillForms, "explore-forms", cfg.FillForms, "Fill form fields with dummy data to trigger form-related JS")
+	cmd.Flags().BoolVar(&cfg.SubmitForms, "explore-forms-submit", cfg.SubmitForms, "Also submit in-scope forms not on the deny-list (implies --explore-forms)")
 
 	// Browser
 	cmd.Flags().StringVar(&cfg.ChromePath, "chrome-path", cfg.ChromePath, "Path to Chrome/Chromium binary (optional)")
+	cmd.Flags().StringVar(&cfg.Isolation, "isolation", cfg.Isolation, "Separate browser context (cookies, storage, cache, service workers) per seed|worker|page, or none to share one")
+	cmd.Flags().StringArrayVarP(&cfg.Headers, "header", "H", cfg.Headers, "Extra request header \"Name: value\" (can be used multiple times)")
+	cmd.Flags().StringArrayVar(&cfg.Cookies, "cookie", cfg.Cookies, "Cookie \"name=value\" (or \"a=1; b=2\") set for every scope host in each browser context (can be used multiple times)")
+	cmd.Flags().IntVar(&cfg.Browsers, "browsers", cfg.Browsers, "Browser processes to spread tabs over; crashed browsers are restarted and their pages retried")
+	cmd.Flags().IntVar(&cfg.TabReuse, "tab-reuse", cfg.TabReuse, "Pages each worker's tab serves (reset to about:blank in between) before it is replaced; 0 or 1 opens a new tab per page")
+	cmd.Flags().IntVar(&cfg.RecycleAfter, "recycle-after", cfg.RecycleAfter, "Restart each browser after this many pages (0 = never)")
+	cmd.Flags().IntVar(&cfg.RecycleRSSMB, "recycle-rss-mb", cfg.RecycleRSSMB, "Restart a browser whose processes use more than this many MB of memory (Linux, 0 = never)")
+	cmd.Flags().StringVar(&cfg.RemoteURL, "remote-debugging-url", cfg.RemoteURL, "Use a running Chrome instead of launching one: ws://host:9222/devtools/browser/..., or http://host:9222 to discover it via /json/version")
 	cmd.Flags().BoolVar(&cfg.Headless, "headless", cfg.Headless, "Run browser in headless mode")
 	cmd.Flags().StringVar(&cfg.UserAgent, "user-agent", cfg.UserAgent, "Custom User-Agent for requests (optional)")
+	cmd.Flags().StringSliceVar(&cfg.BlockTypes, "block", cfg.BlockTypes, "Resource types never downloaded: image|media|font|stylesheet|texttrack|ping|manifest|prefetch|cspviolationreport|other, or none")
+	cmd.Flags().StringArrayVar(&cfg.BlockURLs, "block-url", cfg.BlockURLs, "Also block requests matching this URL pattern ('*' and '?' wildcards; can be used multiple times)")
+	cmd.Flags().StringArrayVar(&cfg.AllowURLs, "allow-url", cfg.AllowURLs, "Never block requests matching this URL pattern, even of a blocked type (can be used multiple times)")
+	cmd.Flags().StringSliceVar(&cfg.Devices, "device", cfg.Devices, "Crawl as device profile desktop|iphone|android|tablet (viewport, touch, UA); several (e.g. desktop,iphone) repeat the crawl once each, tagging records with profile")
+	cmd.Flags().StringSliceVar(&cfg.Regions, "region", cfg.Regions, "Crawl from region us|uk|de|fr|es|it|nl|br|in|jp|au (locale, timezone, geolocation); several repeat the crawl once each, tagging records with locale")
+	cmd.Flags().StringVar(&cfg.Locale, "locale", cfg.Locale, "Language for Accept-Language, navigator.language and Intl (e.g. de-DE); overrides the region's")
+	cmd.Flags().StringVar(&cfg.Timezone, "timezone", cfg.Timezone, "IANA timezone to emulate (e.g. Europe/Berlin); overrides the region's")
+	cmd.Flags().StringVar(&cfg.Geolocation, "geo", cfg.Geolocation, "Geolocation to report as lat,lon[,accuracy] (e.g. 52.52,13.40); overrides the region's")
+	cmd.Flags().BoolVar(&cfg.Stealth, "stealth", cfg.Stealth, "Hide headless-Chrome fingerprints (automation flags, navigator.webdriver, plugins, WebGL vendor, HeadlessChrome UA); only where automated testing is allowed")
 
 	// Output
-	cmd.Flags().StringVarP(&cfg.OutputPath, "output", "o", cfg.OutputPath, "Output path or '-' for STDOUT")
-	cmd.Flags().StringVar(&cfg.Format, "format", cfg.Format, "Output format: txt|jsonl|csv")
+	cmd.Flags().StringArrayVarP(&cfg.Outputs, "output", "o", cfg.Outputs, "Output path or '-' for STDOUT, optionally as format:path; repeat for several outputs (e.g. -o txt:- -o jsonl:out/run.jsonl)")
+	cmd.Flags().StringVar(&cfg.OutputDir, "output-dir", cfg.OutputDir, "Write one file per seed host (in --format txt|jsonl|csv) plus index.json into this directory, as pages complete")
+	cmd.Flags().BoolVar(&cfg.SplitJSHosts, "split-js-host", cfg.SplitJSHosts, "With --output-dir, write one file per JS host under a directory per seed host")
+	cmd.Flags().StringVar(&cfg.TemplatePath, "template", cfg.TemplatePath, "Go text/template file rendered once per JS record by the template format")
+	cmd.Flags().StringVar(&cfg.PagesOutputPath, "pages-output", cfg.PagesOutputPath, "Write per-page records (status, title, timings, errors) to this path or '-' for STDOUT; txt|jsonl|csv from a format: prefix or the extension")
+	cmd.Flags().StringVar(&cfg.GraphPath, "graph", cfg.GraphPath, "Write the page/script/host crawl graph to this path or '-' for STDOUT")
+	cmd.Flags().StringVar(&cfg.GraphFormat, "graph-format", cfg.GraphFormat, "Graph format: dot|graphml|json (default: from --graph extension, else json)")
+	cmd.Flags().StringVar(&cfg.ReportPath, "report", cfg.ReportPath, "Write a self-contained HTML report (summary, page tree, filterable script table) to this path")
+	cmd.Flags().StringVar(&cfg.HARPath, "har", cfg.HARPath, "Write an HTTP Archive (HAR 1.2) of every request made during the crawl to this path or '-' for STDOUT")
+	cmd.Flags().BoolVar(&cfg.HARBodies, "har-bodies", cfg.HARBodies, "Include script and document response bodies in the HAR")
+	cmd.Flags().StringVar(&cfg.Format, "format", cfg.Format, "Default format for -o values without a format prefix: txt|jsonl|csv|html|template|sqlite (sqlite accumulates runs in the -o database; see jscout query)")
+	cmd.Flags().StringVar(&cfg.URLPolicy, "js-url-policy", cfg.URLPolicy, "JS URL normalization for js_url and --unique: strip (all query params)|cachebust (only cache-busters)|keep")
+	cmd.Flags().StringSliceVar(&cfg.JSExtensions, "js-ext", cfg.JSExtensions, "Extra script extensions, optionally with a kind (e.g. .es6,.vue=javascript)")
+	cmd.Flags().StringSliceVar(&cfg.JSMIMETypes, "js-mime", cfg.JSMIMETypes, "Extra script MIME types, optionally with a kind (e.g. text/x-component=javascript)")
+	cmd.Flags().BoolVar(&cfg.Sniff, "sniff", cfg.Sniff, "Sniff bodies of text/plain and octet-stream fetch/XHR responses for JSONP, extensionless JS and WASM")
 	cmd.Flags().BoolVar(&cfg.Unique, "unique", cfg.Unique, "De-duplicate JS URLs in output (txt mode)")
 	cmd.Flags().BoolVar(&cfg.JSInScope, "js-in-scope", cfg.JSInScope, "Only output JS URLs whose host matches scope")
 	cmd.Flags().BoolVar(&cfg.NoBanner, "no-banner", cfg.NoBanner, "Disable startup banner")
 	cmd.Flags().BoolVar(&cfg.Silent, "silent", cfg.Silent, "Silent mode (suppress all log output except errors)")
 
+	cmd.AddCommand(newQueryCmd())
+
 	// Map -u to cfg.SeedsRaw for runner
 	cmd.PreRun = func(cmd *cobra.Command, args []string) {
 		cfg.SeedsRaw = append(cfg.SeedsRaw, cfg.URLs...)
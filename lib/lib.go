@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyinnove/jscout/pkg/engine"
+	"github.com/cyinnove/jscout/pkg/har"
 	"github.com/cyinnove/jscout/pkg/model"
 	"github.com/cyinnove/jscout/utils"
 )
@@ -17,17 +18,92 @@ type Options struct {
 	// AllowedHosts restricts crawl scope by host suffix. If empty, defaults to seed hosts.
 	AllowedHosts []string
 
+	// SeedScopes crawls the listed seeds as independent crawls with their
+	// own allowed hosts (and their own MaxPages), sharing workers and browsers.
+	SeedScopes map[string][]string
+
 	// Browser/runtime
 	ChromePath string
 	Headless   bool
 	UserAgent  string
+	Stealth    bool     // hide headless fingerprints; pages report bot challenges in Challenge either way
+	Devices    []string // crawl once per device profile (desktop|iphone|android|tablet); records carry Profile
+	BlockTypes []string // resource types never downloaded; nil blocks image, media, font and stylesheet, "none" nothing
+	BlockURLs  []string // extra URL patterns ('*', '?') to block
+	AllowURLs  []string // URL patterns never blocked
+	RemoteURL  string   // DevTools endpoint of a running Chrome (ws:// or http://host:port) instead of launching one
+
+	// Locales crawls once per locale profile (language, timezone,
+	// geolocation; see LookupLocale), combined with every device. Records
+	// and pages carry the profile's Name in Locale.
+	Locales []LocaleProfile
+
+	// Isolation gives each seed ("seed", the default), worker ("worker") or
+	// page ("page") its own browser context, or shares one ("none").
+	// Cookies ("name=value") are set for every host in scope of the
+	// context's seed (see SeedScopes); Headers are sent with every request.
+	Isolation string
+	Cookies   []string
+	Headers   map[string]string
+
+	// Browser pool: pages are spread over Browsers processes (default 1).
+	// Crashed browsers are restarted and their pages retried; browsers are
+	// recycled after RecycleAfterPages pages or above RecycleRSSMB of memory.
+	Browsers          int
+	TabReuse          int // pages a worker's tab serves before it is replaced; 0 or 1 opens a tab per page
+	RecycleAfterPages int
+	RecycleRSSMB      int
 
 	// Crawl behavior
-	PageTimeout   time.Duration
-	WaitAfterLoad time.Duration
-	MaxDepth      int
-	MaxPages      int
-	Concurrency   int
+	PageTimeout     time.Duration
+	WaitAfterLoad   time.Duration // cap on the WaitUntil wait, after load and after interactions
+	WaitUntil       string        // load|domcontentloaded|networkidle0 (default)|networkidle2|selector:<css>|fixed:<dur>
+	MaxDepth        int
+	MaxPages        int
+	Concurrency     int
+	SeedConcurrency int  // max pages of one seed in progress at once (0 = no cap)
+	SPARoutes       bool // follow client-side routes discovered from SPA routers
+	Initiators      bool // record the script and stack position that loaded each JS
+
+	// URLPolicy normalizes JSURL (and dedupe): "strip" (default), "cachebust"
+	// or "keep". RawURL always holds the URL as requested.
+	URLPolicy string
+
+	// Script detection beyond ".js": extra extension rules (".ext" or
+	// ".ext=kind") and MIME rules ("type" or "type=kind") on top of the
+	// defaults, and body sniffing of generic fetch/XHR responses.
+	JSExtensions []string
+	JSMIMETypes  []string
+	Sniff        bool
+
+	// HAR records every request of the crawl into Result.HAR; HARBodies also
+	// captures script and document response bodies.
+	HAR       bool
+	HARBodies bool
+
+	// Strategy orders the crawl frontier: "bfs", "dfs" or "score". Score
+	// supplies a custom ranking for the score strategy (higher first); when
+	// Strategy is empty and Score is set, the score strategy is used. Any
+	// other name makes Run fail.
+	Strategy string
+	Score    ScoreFunc
+
+	// Interactive exploration: click safe UI elements to trigger lazy JS.
+	// ExploreDeny lists text fragments or CSS selectors that are never clicked.
+	Explore           bool
+	ExploreMaxActions int
+	ExploreDeny       []string
+
+	// Form exploration: fill fields with dummy data and optionally submit
+	// in-scope forms whose action and labels do not match FormDeny.
+	FillForms   bool
+	SubmitForms bool
+	FormDeny    []string
+
+	// OnPage streams each visited page and its JS records (nil for failed
+	// pages) while the crawl runs; calls are serialized. Records passed here
+	// are not filtered by FilterJSInScope.
+	OnPage func(page *model.PageRecord, js []*model.JSRecord)
 
 	// Convenience
 	Normalize       bool   // normalize seeds to URLs
@@ -35,23 +111,69 @@ type Options struct {
 	FilterJSInScope bool   // keep only JS whose host is within AllowedHosts
 }
 
+// FrontierItem is a page waiting to be crawled, as passed to a ScoreFunc.
+type FrontierItem = engine.FrontierItem
+
+// LocaleProfile is a language, timezone and geolocation to crawl under.
+type LocaleProfile = engine.LocaleProfile
+
+// Geolocation is a position reported to the Geolocation API.
+type Geolocation = engine.Geolocation
+
+// LookupLocale returns a built-in region (us, uk, de, fr, ...) as a
+// LocaleProfile.
+func LookupLocale(name string) (LocaleProfile, bool) { return engine.LookupLocale(name) }
+
+// ScoreFunc ranks pending pages for the score strategy.
+type ScoreFunc = engine.ScoreFunc
+
+// DefaultScorer returns the built-in ScoreFunc so custom scorers can wrap it.
+func DefaultScorer() ScoreFunc { return engine.NewDefaultScorer() }
+
 // DefaultOptions returns a sensible default Options value.
 func DefaultOptions() Options {
 	return Options{
-		Headless:        true,
-		PageTimeout:     30 * time.Second,
-		WaitAfterLoad:   3 * time.Second,
-		MaxDepth:        1,
-		MaxPages:        100,
-		Concurrency:     4,
-		Normalize:       true,
-		DefaultScheme:   "https",
-		FilterJSInScope: true,
+		Headless:          true,
+		PageTimeout:       30 * time.Second,
+		WaitAfterLoad:     3 * time.Second,
+		MaxDepth:          1,
+		MaxPages:          100,
+		Concurrency:       4,
+		Browsers:          1,
+		TabReuse:          0,
+		SPARoutes:         false,
+		Initiators:        false,
+		Sniff:             false,
+		ExploreMaxActions: 20,
+		ExploreDeny:       append([]string(nil), engine.DefaultExploreDenyList...),
+		FormDeny:          append([]string(nil), engine.DefaultFormDenyList...),
+		Normalize:         true,
+		DefaultScheme:     "https",
+		FilterJSInScope:   true,
 	}
 }
 
-// Crawl runs the crawl with the provided options and returns discovered JS records.
+// Result holds everything produced by a crawl.
+type Result struct {
+	JS    []*model.JSRecord
+	Pages []*model.PageRecord
+	HAR   *har.Log // nil unless Options.HAR
+}
+
+// Crawl runs the crawl with the provided options and returns discovered JS
+// records; like Run, it returns the partial records along with a crawl error.
 func Crawl(o Options) ([]*model.JSRecord, error) {
+	res, err := Run(o)
+	if res == nil {
+		return nil, err
+	}
+	return res.JS, err
+}
+
+// Run runs the crawl with the provided options and returns JS and page records.
+// When the crawl fails part way (e.g. the browser cannot be restarted), the
+// records and pages collected until then are returned along with the error.
+func Run(o Options) (*Result, error) {
 	seeds := make([]string, 0, len(o.Seeds))
 	if o.Normalize {
 		scheme := o.DefaultScheme
@@ -83,28 +205,57 @@ func Crawl(o Options) ([]*model.JSRecord, error) {
 	}
 
 	engOpt := engine.Options{
-		AllowedHosts:  allowed,
-		ChromePath:    o.ChromePath,
-		Headless:      o.Headless,
-		UserAgent:     o.UserAgent,
-		PageTimeout:   o.PageTimeout,
-		WaitAfterLoad: o.WaitAfterLoad,
-		MaxDepth:      o.MaxDepth,
-		MaxPages:      o.MaxPages,
-		Concurrency:   o.Concurrency,
+		AllowedHosts:      allowed,
+		ChromePath:        o.ChromePath,
+		RemoteURL:         o.RemoteURL,
+		Browsers:          o.Browsers,
+		TabReuse:          o.TabReuse,
+		Isolation:         o.Isolation,
+		Cookies:           o.Cookies,
+		Headers:           o.Headers,
+		RecycleAfterPages: o.RecycleAfterPages,
+		RecycleRSSMB:      o.RecycleRSSMB,
+		Headless:          o.Headless,
+		UserAgent:         o.UserAgent,
+		Stealth:           o.Stealth,
+		Devices:           o.Devices,
+		Locales:           o.Locales,
+		BlockTypes:        o.BlockTypes,
+		BlockURLs:         o.BlockURLs,
+		AllowURLs:         o.AllowURLs,
+		PageTimeout:       o.PageTimeout,
+		WaitAfterLoad:     o.WaitAfterLoad,
+		WaitUntil:         o.WaitUntil,
+		MaxDepth:          o.MaxDepth,
+		MaxPages:          o.MaxPages,
+		Concurrency:       o.Concurrency,
+		SeedConcurrency:   o.SeedConcurrency,
+		SeedScopes:        o.SeedScopes,
+		SPARoutes:         o.SPARoutes,
+		Initiators:        o.Initiators,
+		URLPolicy:         o.URLPolicy,
+		Classifier:        engine.NewClassifier(o.JSExtensions, o.JSMIMETypes),
+		Sniff:             o.Sniff,
+		HAR:               o.HAR,
+		HARBodies:         o.HARBodies,
+		Strategy:          o.Strategy,
+		Score:             o.Score,
+		Explore:           o.Explore,
+		ExploreMaxActions: o.ExploreMaxActions,
+		ExploreDeny:       o.ExploreDeny,
+		FillForms:         o.FillForms || o.SubmitForms,
+		SubmitForms:       o.SubmitForms,
+		FormDeny:          o.FormDeny,
+		OnPage:            o.OnPage,
 	}
 
 	eng := engine.New(engOpt)
 	records, err := eng.Crawl(seeds)
-	if err != nil {
-		return nil, err
-	}
-
 	if o.FilterJSInScope {
 		records = FilterJSInScope(records, allowed)
 	}
 
-	return records, nil
+	return &Result{JS: records, Pages: eng.Pages(), HAR: eng.HAR()}, err
 }
 
 // FilterJSInScope returns only JS records whose JSURL host matches allowed host suffixes.
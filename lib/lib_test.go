@@ -18,6 +18,48 @@ func TestDefaultOptions(t *testing.T) {
     if o.PageTimeout <= 0 || o.WaitAfterLoad < 0 {
         t.Fatalf("invalid timeouts: page=%v wait=%v", o.PageTimeout, o.WaitAfterLoad)
     }
+    if o.Explore || len(o.ExploreDeny) == 0 {
+        t.Fatalf("expected exploration off with a default deny-list, got explore=%v deny=%d", o.Explore, len(o.ExploreDeny))
+    }
+    if o.SPARoutes {
+        t.Fatalf("expected SPA route discovery to be opt-in")
+    }
+    if o.Initiators {
+        t.Fatalf("expected initiator capture to be opt-in")
+    }
+    if o.Sniff {
+        t.Fatalf("expected body sniffing to be opt-in")
+    }
+    if o.TabReuse != 0 {
+        t.Fatalf("expected a new tab per page by default, got tab-reuse=%d", o.TabReuse)
+    }
+}
+
+func TestDefaultScorerPrefersAppAreas(t *testing.T) {
+    score := DefaultScorer()
+    legal := score(FrontierItem{URL: "https://example.com/legal/privacy", Depth: 1})
+    admin := score(FrontierItem{URL: "https://example.com/admin/users", Depth: 1})
+    if admin <= legal {
+        t.Fatalf("expected admin page to outrank legal page: admin=%v legal=%v", admin, legal)
+    }
+    first := score(FrontierItem{URL: "https://example.com/item/1", Depth: 1})
+    repeat := score(FrontierItem{URL: "https://example.com/item/2", Depth: 1})
+    if repeat >= first {
+        t.Fatalf("expected repeated URL pattern to score lower: first=%v repeat=%v", first, repeat)
+    }
+}
+
+func TestRunReturnsResultWithError(t *testing.T) {
+    o := DefaultOptions()
+    o.Seeds = []string{"https://example.com"}
+    o.Strategy = "best-first"
+    res, err := Run(o)
+    if err == nil {
+        t.Fatalf("expected an error for an unknown strategy")
+    }
+    if res == nil {
+        t.Fatalf("expected a (partial) result along with the error")
+    }
 }
 
 func TestFilterJSInScope(t *testing.T) {
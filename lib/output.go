@@ -2,8 +2,12 @@ package lib
 
 import (
     "io"
+    "time"
 
+    "github.com/cyinnove/jscout/pkg/graph"
+    "github.com/cyinnove/jscout/pkg/har"
     "github.com/cyinnove/jscout/pkg/model"
+    "github.com/cyinnove/jscout/pkg/store"
     "github.com/cyinnove/jscout/utils"
 )
 
@@ -12,3 +16,54 @@ func WriteOutput(w io.Writer, format string, unique bool, records []*model.JSRec
     return utils.WriteOutput(w, format, unique, records)
 }
 
+// OutputData is what a format writer renders; FormatWriter renders it.
+type (
+    OutputData   = utils.OutputData
+    FormatWriter = utils.FormatWriter
+)
+
+// RegisterFormat adds or replaces an output format, usable from WriteFormat
+// and from the CLI's -o format:path.
+func RegisterFormat(name string, fn FormatWriter) {
+    utils.RegisterFormat(name, fn)
+}
+
+// WriteFormat renders data in any registered format (txt, jsonl, csv, html,
+// template or a custom one).
+func WriteFormat(w io.Writer, format string, data OutputData) error {
+    return utils.Write(w, format, data)
+}
+
+// WritePages writes page records using the same formats as WriteOutput.
+func WritePages(w io.Writer, format string, pages []*model.PageRecord) error {
+    return utils.WritePages(w, format, pages)
+}
+
+// WriteReport writes a self-contained HTML report of a crawl. Scripts outside
+// scope are marked third-party; with no scope the source page's base domain
+// is used.
+func WriteReport(w io.Writer, res *Result, scope []string) error {
+    return utils.WriteHTMLReport(w, utils.Report{Scope: scope, Records: res.JS, Pages: res.Pages})
+}
+
+// WriteHAR writes the HTTP Archive of a crawl (Result.HAR).
+func WriteHAR(w io.Writer, log *har.Log) error {
+    return har.Write(w, log)
+}
+
+// WriteGraph writes the page/script/host graph of a crawl as dot, graphml or json.
+func WriteGraph(w io.Writer, format string, pages []*model.PageRecord, records []*model.JSRecord) error {
+    return graph.Write(w, format, graph.Build(pages, records))
+}
+
+// SaveRun adds a crawl result to the sqlite database at dbPath (created if
+// missing) and returns the run id; see store.Store for querying it.
+func SaveRun(dbPath string, seeds []string, started time.Time, res *Result) (int64, error) {
+    st, err := store.Open(dbPath)
+    if err != nil {
+        return 0, err
+    }
+    defer st.Close()
+    run := store.Run{StartedAt: started, FinishedAt: time.Now(), Seeds: seeds}
+    return st.SaveRun(run, res.JS, res.Pages, nil)
+}
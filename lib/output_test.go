@@ -3,10 +3,17 @@ package lib
 import (
     "bytes"
     "encoding/csv"
+    "encoding/json"
+    "io"
+    "os"
+    "path/filepath"
     "strings"
     "testing"
+    "time"
 
+    "github.com/cyinnove/jscout/pkg/har"
     "github.com/cyinnove/jscout/pkg/model"
+    "github.com/cyinnove/jscout/pkg/store"
 )
 
 func TestWriteOutputTXTUnique(t *testing.T) {
@@ -47,5 +54,164 @@ func TestWriteOutputCSV(t *testing.T) {
     if len(rows) != 2 {
         t.Fatalf("expected header + 1 row, got %d", len(rows))
     }
+    if len(rows[0]) != len(rows[1]) || rows[0][0] != "js_url" || rows[0][4] != "from_cache" {
+        t.Fatalf("unexpected csv schema: %v / %v", rows[0], rows[1])
+    }
 }
 
+func TestWritePagesTXT(t *testing.T) {
+    pages := []*model.PageRecord{
+        {URL: "https://a/", Status: 200, Title: "Home"},
+        {URL: "https://b/", ErrorKind: "dns", ErrorCode: "net::ERR_NAME_NOT_RESOLVED"},
+    }
+    var buf bytes.Buffer
+    if err := WritePages(&buf, "txt", pages); err != nil {
+        t.Fatalf("write pages: %v", err)
+    }
+    s := buf.String()
+    if !strings.Contains(s, "https://a/ [200] [Home]") || !strings.Contains(s, "[dns net::ERR_NAME_NOT_RESOLVED]") {
+        t.Fatalf("unexpected pages txt: %s", s)
+    }
+}
+
+func TestWriteGraphDOT(t *testing.T) {
+    pages := []*model.PageRecord{{URL: "https://a/", Links: []string{"https://a/admin"}}}
+    recs := []*model.JSRecord{{JSURL: "https://cdn.a/main.js", SourcePage: "https://a/"}}
+    var buf bytes.Buffer
+    if err := WriteGraph(&buf, "dot", pages, recs); err != nil {
+        t.Fatalf("write graph: %v", err)
+    }
+    s := buf.String()
+    for _, want := range []string{
+        `"page:https://a/" -> "page:https://a/admin" [label="links_to"]`,
+        `"page:https://a/" -> "script:https://cdn.a/main.js" [label="loads"]`,
+        `"script:https://cdn.a/main.js" -> "host:cdn.a" [label="hosted_on"]`,
+    } {
+        if !strings.Contains(s, want) {
+            t.Fatalf("missing %q in dot output:\n%s", want, s)
+        }
+    }
+}
+
+func TestWriteHARSortsEntries(t *testing.T) {
+    log := har.NewLog()
+    log.Entries = append(log.Entries,
+        har.Entry{StartedDateTime: "2024-01-15T10:30:02.000Z", Request: har.Request{URL: "https://a/late.js"}},
+        har.Entry{StartedDateTime: "2024-01-15T10:30:01.000Z", Request: har.Request{URL: "https://a/"}},
+    )
+    var buf bytes.Buffer
+    if err := WriteHAR(&buf, log); err != nil {
+        t.Fatalf("write har: %v", err)
+    }
+    var doc struct {
+        Log struct {
+            Version string `json:"version"`
+            Entries []struct {
+                Request struct{ URL string } `json:"request"`
+            } `json:"entries"`
+        } `json:"log"`
+    }
+    if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+        t.Fatalf("decode har: %v", err)
+    }
+    if doc.Log.Version != "1.2" || len(doc.Log.Entries) != 2 || doc.Log.Entries[0].Request.URL != "https://a/" {
+        t.Fatalf("unexpected har: %s", buf.String())
+    }
+}
+
+func TestSaveRunTracksFirstSeen(t *testing.T) {
+    db := filepath.Join(t.TempDir(), "jscout.db")
+    first := &Result{
+        JS:    []*model.JSRecord{{JSURL: "https://app.example.com/main.js", ResourceKind: "javascript"}},
+        Pages: []*model.PageRecord{{URL: "https://app.example.com/", Links: []string{"https://app.example.com/login"}}},
+    }
+    if _, err := SaveRun(db, []string{"https://app.example.com/"}, time.Now(), first); err != nil {
+        t.Fatalf("save first run: %v", err)
+    }
+    second := &Result{JS: []*model.JSRecord{
+        {JSURL: "https://app.example.com/main.js", ResourceKind: "javascript"},
+        {JSURL: "https://cdn.other.com/chunk.mjs", ResourceKind: "module"},
+    }}
+    runID, err := SaveRun(db, nil, time.Now(), second)
+    if err != nil {
+        t.Fatalf("save second run: %v", err)
+    }
+
+    st, err := store.Open(db)
+    if err != nil {
+        t.Fatalf("open: %v", err)
+    }
+    defer st.Close()
+    all, err := st.Scripts(store.Filter{RunID: runID})
+    if err != nil || len(all) != 2 {
+        t.Fatalf("expected 2 scripts in run %d, got %d (%v)", runID, len(all), err)
+    }
+    mods, err := st.Scripts(store.Filter{Kinds: []string{"module"}, Hosts: []string{"other.com"}})
+    if err != nil || len(mods) != 1 || mods[0].JSURL != "https://cdn.other.com/chunk.mjs" {
+        t.Fatalf("kind/host filter: %v %v", mods, err)
+    }
+    eps, err := st.Endpoints(store.Filter{Hosts: []string{"example.com"}})
+    if err != nil || len(eps) != 1 || eps[0].SourcePage != "https://app.example.com/" {
+        t.Fatalf("endpoints: %v %v", eps, err)
+    }
+}
+
+func TestWriteReportSelfContained(t *testing.T) {
+    res := &Result{
+        JS: []*model.JSRecord{
+            {JSURL: "https://app.example.com/main.js", SourcePage: "https://app.example.com/", ResourceKind: "module",
+                Headers: map[string]string{"sourcemap": "main.js.map"}},
+            {JSURL: "https://cdn.other.com/x.js?<b>", SourcePage: "https://app.example.com/"},
+        },
+        Pages: []*model.PageRecord{
+            {URL: "https://app.example.com/", Title: "Home"},
+            {URL: "https://app.example.com/about", Parent: "https://app.example.com/", Depth: 1},
+        },
+    }
+    var buf bytes.Buffer
+    if err := WriteReport(&buf, res, nil); err != nil {
+        t.Fatalf("write report: %v", err)
+    }
+    out := buf.String()
+    for _, bad := range []string{"<script src", "<link ", "x.js?<b>"} {
+        if strings.Contains(out, bad) {
+            t.Fatalf("report contains %q", bad)
+        }
+    }
+    for _, want := range []string{`data-party="third" data-host="cdn.other.com"`, "https://app.example.com/main.js.map", "<ul class=\"tree\"><li><a href=\"https://app.example.com/about\">"} {
+        if !strings.Contains(out, want) {
+            t.Fatalf("report missing %q", want)
+        }
+    }
+}
+
+func TestWriteFormatTemplateAndCustom(t *testing.T) {
+    tmpl := filepath.Join(t.TempDir(), "rec.tmpl")
+    if err := os.WriteFile(tmpl, []byte(`{{host .JSURL}} {{.Status}}`), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    data := OutputData{
+        Records:      []*model.JSRecord{{JSURL: "https://a.com/x.js", Status: 200}, {JSURL: "https://a.com/x.js", Status: 200}},
+        Unique:       true,
+        TemplatePath: tmpl,
+    }
+    var buf bytes.Buffer
+    if err := WriteFormat(&buf, "template", data); err != nil {
+        t.Fatalf("write template: %v", err)
+    }
+    if got := buf.String(); got != "a.com 200\n" {
+        t.Fatalf("unexpected template output %q", got)
+    }
+
+    RegisterFormat("count", func(w io.Writer, d OutputData) error {
+        _, err := io.WriteString(w, strings.Repeat("x", len(d.Records)))
+        return err
+    })
+    buf.Reset()
+    if err := WriteFormat(&buf, "COUNT", data); err != nil || buf.String() != "xx" {
+        t.Fatalf("custom format: %q, %v", buf.String(), err)
+    }
+    if err := WriteFormat(&buf, "nope", data); err == nil {
+        t.Fatal("expected unknown format error")
+    }
+}
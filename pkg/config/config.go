@@ -15,22 +15,73 @@ type Config struct {
 	ScopeList []string // final computed list
 
 	// Crawl controls
-	MaxDepth       int
-	MaxPages       int
-	WaitSeconds    int
-	PageTimeoutSec int
-	Concurrency    int
+	MaxDepth        int
+	MaxPages        int
+	WaitSeconds     int
+	WaitUntil       string // wait strategy: load|domcontentloaded|networkidle0|networkidle2|selector:<css>|fixed:<dur>
+	PageTimeoutSec  int
+	Concurrency     int
+	SeedConcurrency int // max pages of one seed in progress at once (0 = no cap)
+	SPARoutes       bool
+	Initiators      bool
+	Strategy        string // frontier order: bfs|dfs|score
+
+	// Interactive exploration
+	Explore           bool
+	ExploreMaxActions int
+	ExploreDeny       []string // extra deny-list entries (text or CSS selector)
+	ExploreSafe       bool     // apply the built-in deny-lists
+	FillForms         bool
+	SubmitForms       bool
 
 	// Browser
 	ChromePath string
 	Headless   bool
 	UserAgent  string
+	Stealth    bool     // hide headless fingerprints (automation flags, navigator.webdriver, ...)
+	Devices    []string // device profiles to crawl as, once each: desktop|iphone|android|tablet
+	BlockTypes []string // resource types never downloaded (none = block no type)
+	BlockURLs  []string // extra URL patterns to block
+	AllowURLs  []string // URL patterns never blocked
+	RemoteURL  string   // DevTools endpoint of a running Chrome; skips launching one
+
+	// Locale emulation: each region is crawled once; Locale, Timezone and
+	// Geolocation ("lat,lon[,accuracy]") override its fields or, without
+	// regions, make up a single profile
+	Regions     []string
+	Locale      string
+	Timezone    string
+	Geolocation string
+
+	// Isolation and identity
+	Isolation string   // seed|worker|page|none: browser context per unit
+	Headers   []string // extra request headers, "Name: value"
+	Cookies   []string // "name=value" cookies set for every scope host
+
+	// Browser pool
+	Browsers     int // browser processes pages are spread over
+	TabReuse     int // pages a worker's tab serves before it is replaced (0/1 = new tab per page)
+	RecycleAfter int // restart a browser after this many pages (0 = never)
+	RecycleRSSMB int // restart a browser above this resident memory (0 = never)
 
 	// Output
-	OutputPath string
-	Format     string
-	Unique     bool
-	JSInScope  bool
+	Outputs         []string // "-o" values: path or format:path
+	TemplatePath    string   // text/template file for the template format
+	OutputDir       string   // optional per-seed-host split output with index.json
+	SplitJSHosts    bool     // split OutputDir further into one file per JS host
+	PagesOutputPath string   // optional per-page records ("format:path" or by extension)
+	GraphPath       string   // optional crawl graph export
+	GraphFormat     string   // dot|graphml|json; inferred from GraphPath when empty
+	ReportPath      string   // optional self-contained HTML report
+	HARPath         string   // optional HTTP Archive of all crawl traffic
+	HARBodies       bool     // include script and document bodies in the HAR
+	Format          string
+	Unique          bool
+	URLPolicy       string   // JS URL normalization: strip|cachebust|keep
+	JSExtensions    []string // extra script extensions: ".ext" or ".ext=kind"
+	JSMIMETypes     []string // extra script MIME types: "type" or "type=kind"
+	Sniff           bool     // sniff bodies of generic fetch/XHR responses
+	JSInScope       bool
 
 	// Seeds (final normalized elsewhere)
 	SeedsRaw []string
@@ -43,17 +94,29 @@ type Config struct {
 // Defaults returns a Config initialized with sane defaults.
 func Defaults() Config {
 	return Config{
-		Scheme:         "https",
-		MaxDepth:       1,
-		MaxPages:       100,
-		WaitSeconds:    3,
-		PageTimeoutSec: 30,
-		Concurrency:    4,
-		Headless:       true,
-		OutputPath:     "-",
-		Format:         "txt",
-		Unique:         true,
-		JSInScope:      true,
-		NoBanner:       false,
+		Scheme:            "https",
+		MaxDepth:          1,
+		MaxPages:          100,
+		WaitSeconds:       3,
+		WaitUntil:         "networkidle0",
+		PageTimeoutSec:    30,
+		Concurrency:       4,
+		Browsers:          1,
+		TabReuse:          0,
+		Isolation:         "seed",
+		BlockTypes:        []string{"image", "media", "font", "stylesheet"},
+		SPARoutes:         false,
+		Initiators:        false,
+		Strategy:          "bfs",
+		ExploreMaxActions: 20,
+		ExploreSafe:       true,
+		Headless:          true,
+		Outputs:           []string{"-"},
+		Format:            "txt",
+		Unique:            true,
+		URLPolicy:         "strip",
+		Sniff:             false,
+		JSInScope:         true,
+		NoBanner:          false,
 	}
 }
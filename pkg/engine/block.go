@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultBlockTypes are the resource types blocked when Options.BlockTypes
+// is nil: nothing a crawl for scripts needs to download.
+var DefaultBlockTypes = []string{"image", "media", "font", "stylesheet"}
+
+// blockTypeNames maps accepted names onto CDP resource types. Documents and
+// scripts cannot be blocked by type; that is what jscout is looking for.
+var blockTypeNames = map[string]network.ResourceType{
+	"image":              network.ResourceTypeImage,
+	"img":                network.ResourceTypeImage,
+	"media":              network.ResourceTypeMedia,
+	"font":               network.ResourceTypeFont,
+	"stylesheet":         network.ResourceTypeStylesheet,
+	"css":                network.ResourceTypeStylesheet,
+	"texttrack":          network.ResourceTypeTextTrack,
+	"ping":               network.ResourceTypePing,
+	"manifest":           network.ResourceTypeManifest,
+	"prefetch":           network.ResourceTypePrefetch,
+	"cspviolationreport": network.ResourceTypeCSPViolationReport,
+	"other":              network.ResourceTypeOther,
+}
+
+// blockPolicy decides which requests of a page are failed before they are
+// sent: every request of a blocked resource type or matching a block
+// pattern, unless it matches an allow pattern. The page's own document is
+// never blocked.
+type blockPolicy struct {
+	patterns []*fetch.RequestPattern
+	allow    []*regexp.Regexp
+}
+
+// newBlockPolicy builds the policy from Options.BlockTypes (nil for
+// DefaultBlockTypes, "none" for no type), BlockURLs and AllowURLs.
+func newBlockPolicy(opt Options) (*blockPolicy, error) {
+	names := opt.BlockTypes
+	if names == nil {
+		names = DefaultBlockTypes
+	}
+	types, err := BlockResourceTypes(names)
+	if err != nil {
+		return nil, err
+	}
+	p := &blockPolicy{}
+	for _, rt := range types {
+		p.patterns = append(p.patterns, &fetch.RequestPattern{URLPattern: "*", ResourceType: rt})
+	}
+	for _, glob := range opt.BlockURLs {
+		if glob = strings.TrimSpace(glob); glob != "" {
+			p.patterns = append(p.patterns, &fetch.RequestPattern{URLPattern: glob})
+		}
+	}
+	for _, glob := range opt.AllowURLs {
+		if glob = strings.TrimSpace(glob); glob != "" {
+			p.allow = append(p.allow, globRegexp(glob))
+		}
+	}
+	return p, nil
+}
+
+// BlockResourceTypes resolves resource type names for Options.BlockTypes;
+// "none" stands for no type.
+func BlockResourceTypes(names []string) ([]network.ResourceType, error) {
+	var types []network.ResourceType
+	seen := make(map[network.ResourceType]struct{}, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "none" {
+			continue
+		}
+		rt, ok := blockTypeNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown resource type to block: %s (use image|media|font|stylesheet|texttrack|ping|manifest|prefetch|cspviolationreport|other|none)", name)
+		}
+		if _, dup := seen[rt]; dup {
+			continue
+		}
+		seen[rt] = struct{}{}
+		types = append(types, rt)
+	}
+	return types, nil
+}
+
+// globRegexp compiles a Fetch URL pattern ('*' any run, '?' one character,
+// backslash escapes) into an anchored regexp.
+func globRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func (p *blockPolicy) allowed(u string) bool {
+	for _, re := range p.allow {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// install makes the tab in ctx pause matching requests and fail or
+// continue them. It returns how many requests have been blocked so far,
+// and a cleanup that turns interception off again: a reused tab must not
+// keep pausing requests nobody answers.
+func (p *blockPolicy) install(ctx context.Context, mainFrame cdp.FrameID) (blocked func() int, cleanup func(), err error) {
+	var mu sync.Mutex
+	count := 0
+	blocked = func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return count
+	}
+	if len(p.patterns) == 0 {
+		return blocked, func() {}, nil
+	}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		e, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+		block := e.Request != nil && !p.allowed(e.Request.URL) &&
+			!(e.ResourceType == network.ResourceTypeDocument && e.FrameID == mainFrame)
+		if block {
+			mu.Lock()
+			count++
+			mu.Unlock()
+		}
+		// Answering is a CDP round trip; never block the event loop
+		go func() {
+			exec := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+			if block {
+				_ = fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient).Do(exec)
+			} else {
+				_ = fetch.ContinueRequest(e.RequestID).Do(exec)
+			}
+		}()
+	})
+	if err := chromedp.Run(ctx, fetch.Enable().WithPatterns(p.patterns)); err != nil {
+		return blocked, func() {}, err
+	}
+	cleanup = func() {
+		dctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tabResetTimeout)
+		defer cancel()
+		_ = chromedp.Run(dctx, fetch.Disable())
+	}
+	return blocked, cleanup, nil
+}
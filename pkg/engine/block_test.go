@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestBlockResourceTypes(t *testing.T) {
+	cases := []struct {
+		names   []string
+		want    []network.ResourceType
+		wantErr bool
+	}{
+		{names: nil, want: nil},
+		{names: []string{"none"}, want: nil},
+		{names: DefaultBlockTypes, want: []network.ResourceType{
+			network.ResourceTypeImage, network.ResourceTypeMedia, network.ResourceTypeFont, network.ResourceTypeStylesheet}},
+		{names: []string{" IMG ", "image", "css", ""}, want: []network.ResourceType{
+			network.ResourceTypeImage, network.ResourceTypeStylesheet}},
+		{names: []string{"ping", "none", "other"}, want: []network.ResourceType{
+			network.ResourceTypePing, network.ResourceTypeOther}},
+		{names: []string{"script"}, wantErr: true},
+		{names: []string{"document"}, wantErr: true},
+		{names: []string{"image", "gif"}, wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := BlockResourceTypes(c.names)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("BlockResourceTypes(%v): expected error, got %v", c.names, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("BlockResourceTypes(%v) = %v, %v; want %v", c.names, got, err, c.want)
+		}
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	cases := []struct {
+		glob, url string
+		want      bool
+	}{
+		{"*", "https://a.com/x.png", true},
+		{"*.png", "https://a.com/x.png", true},
+		{"*.png", "https://a.com/x.png?v=1", false},
+		{"*.png*", "https://a.com/x.png?v=1", true},
+		{"*://*.doubleclick.net/*", "https://ad.doubleclick.net/pixel", true},
+		{"*://*.doubleclick.net/*", "https://doubleclick.net.evil.com/", false},
+		{"https://a.com/img?.gif", "https://a.com/img1.gif", true},
+		{"https://a.com/img?.gif", "https://a.com/img12.gif", false},
+		{"https://a.com/a+b(1).js", "https://a.com/a+b(1).js", true},
+		{"https://a.com/a+b(1).js", "https://a.com/aab(1).js", false},
+		{`https://a.com/\*.js`, "https://a.com/*.js", true},
+		{`https://a.com/\*.js`, "https://a.com/x.js", false},
+		{`https://a.com/x.js\`, "https://a.com/x.js", true},
+	}
+	for _, c := range cases {
+		if got := globRegexp(c.glob).MatchString(c.url); got != c.want {
+			t.Errorf("globRegexp(%q) on %q = %v, want %v", c.glob, c.url, got, c.want)
+		}
+	}
+}
+
+func TestNewBlockPolicy(t *testing.T) {
+	cases := []struct {
+		name     string
+		opt      Options
+		patterns int
+		allowed  string
+		wantErr  bool
+	}{
+		{name: "defaults", opt: Options{}, patterns: len(DefaultBlockTypes)},
+		{name: "none", opt: Options{BlockTypes: []string{"none"}}},
+		{name: "urls", opt: Options{BlockTypes: []string{"none"}, BlockURLs: []string{"*analytics*", " "}}, patterns: 1},
+		{name: "allow", opt: Options{BlockTypes: []string{"font"}, AllowURLs: []string{"*://fonts.example.com/*"}},
+			patterns: 1, allowed: "https://fonts.example.com/a.woff2"},
+		{name: "unknown type", opt: Options{BlockTypes: []string{"scripts"}}, wantErr: true},
+	}
+	for _, c := range cases {
+		p, err := newBlockPolicy(c.opt)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(p.patterns) != c.patterns {
+			t.Errorf("%s: %d patterns, want %d", c.name, len(p.patterns), c.patterns)
+		}
+		if c.allowed != "" && !p.allowed(c.allowed) {
+			t.Errorf("%s: %s not allowed", c.name, c.allowed)
+		}
+		if p.allowed("https://other.example.com/a.woff2") {
+			t.Errorf("%s: unexpected allow", c.name)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// Resource kinds reported in model.JSRecord.ResourceKind.
+const (
+	KindJavaScript = "javascript"
+	KindModule     = "module"
+	KindCommonJS   = "commonjs"
+	KindJSONP      = "jsonp"
+	KindTypeScript = "typescript"
+	KindWasm       = "wasm"
+)
+
+// sniffLimit caps the response bytes inspected when sniffing content.
+const sniffLimit = 4096
+
+// Classifier decides whether a response is JavaScript (or WebAssembly) and
+// of which kind, using extension and MIME rules, the CDP resource type and,
+// for ambiguous responses, the response body.
+type Classifier struct {
+	extensions map[string]string // ".mjs" -> kind
+	mimes      map[string]string // "text/javascript" -> kind (parameters ignored)
+}
+
+var (
+	defaultExtensions = map[string]string{
+		".js": KindJavaScript, ".jsx": KindJavaScript,
+		".mjs":   KindModule,
+		".cjs":   KindCommonJS,
+		".jsonp": KindJSONP,
+		".ts":    KindTypeScript, ".tsx": KindTypeScript, ".mts": KindTypeScript, ".cts": KindTypeScript,
+		".wasm": KindWasm,
+	}
+	defaultMIMEs = map[string]string{
+		"application/javascript":   KindJavaScript,
+		"text/javascript":          KindJavaScript,
+		"application/x-javascript": KindJavaScript,
+		"application/ecmascript":   KindJavaScript,
+		"text/ecmascript":          KindJavaScript,
+		"text/jsx":                 KindJavaScript,
+		"application/typescript":   KindTypeScript,
+		"text/typescript":          KindTypeScript,
+		"application/x-typescript": KindTypeScript,
+		"application/wasm":         KindWasm,
+	}
+	// sniffMIMEs are content types too generic to trust either way.
+	sniffMIMEs = map[string]struct{}{
+		"": {}, "text/plain": {}, "application/octet-stream": {}, "binary/octet-stream": {},
+	}
+	// jsonpCallbackParams name the callback of a JSONP endpoint. "cb" is left
+	// out: it is far more often a cache buster (see utils.NormalizeJSURL).
+	jsonpCallbackParams = []string{"callback", "jsonp", "jsonpcallback"}
+
+	jsonpBody = regexp.MustCompile(`^\s*(?:/\*\*/\s*)?[A-Za-z_$][\w$.]*\s*\(\s*[\[{"']`)
+	jsBody    = regexp.MustCompile(`(?:^|[;\s])(?:function\s*[\w$]*\s*\(|(?:var|let|const)\s+[\w$]+\s*=|import\s*[\s{*"'(]|export\s+(?:default|const|function|class|\{)|"use strict"|\(\s*function\s*\(|=>\s*\{)|webpackChunk|__webpack_require__`)
+)
+
+// NewClassifier returns the default classifier extended with extra rules.
+// Each rule is "ext" or "ext=kind" for extensions (".vue", ".es6=javascript")
+// and "type" or "type=kind" for MIME types; the kind defaults to javascript.
+func NewClassifier(extraExtensions, extraMIMEs []string) *Classifier {
+	c := &Classifier{extensions: map[string]string{}, mimes: map[string]string{}}
+	for k, v := range defaultExtensions {
+		c.extensions[k] = v
+	}
+	for k, v := range defaultMIMEs {
+		c.mimes[k] = v
+	}
+	for _, r := range extraExtensions {
+		ext, kind := splitRule(r)
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		c.extensions[ext] = kind
+	}
+	for _, r := range extraMIMEs {
+		if mime, kind := splitRule(r); mime != "" {
+			c.mimes[mime] = kind
+		}
+	}
+	return c
+}
+
+func splitRule(r string) (key, kind string) {
+	key, kind, _ = strings.Cut(strings.TrimSpace(r), "=")
+	key = strings.ToLower(strings.TrimSpace(key))
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		kind = KindJavaScript
+	}
+	return key, kind
+}
+
+// Extensions returns the known script extensions, sorted.
+func (c *Classifier) Extensions() []string {
+	out := make([]string, 0, len(c.extensions))
+	for ext := range c.extensions {
+		out = append(out, ext)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// FromURL classifies by file extension and JSONP callback parameters only.
+// It is meant for URLs seen without a response, such as script elements.
+func (c *Classifier) FromURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if kind := c.fromExtension(u); kind != "" {
+		return kind
+	}
+	if hasJSONPCallback(u) {
+		return KindJSONP
+	}
+	return ""
+}
+
+func (c *Classifier) fromExtension(u *url.URL) string {
+	return c.extensions[strings.ToLower(path.Ext(u.Path))]
+}
+
+func hasJSONPCallback(u *url.URL) bool {
+	q := u.Query()
+	for _, p := range jsonpCallbackParams {
+		if q.Get(p) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Classifier) fromMIME(mime string) string {
+	mime = strings.ToLower(strings.TrimSpace(strings.SplitN(mime, ";", 2)[0]))
+	if kind, ok := c.mimes[mime]; ok {
+		return kind
+	}
+	return ""
+}
+
+// Classify decides from response metadata. It returns the kind, or "" and
+// sniff=true when only the body can tell (generic MIME on a fetch/XHR).
+// JSONP callback parameters only decide when the MIME type is generic; a
+// script MIME type wins and any other one (application/json) means data.
+func (c *Classifier) Classify(raw, mime string, rt network.ResourceType) (kind string, sniff bool) {
+	byMIME := c.fromMIME(mime)
+	var byURL string
+	var jsonp bool
+	if u, err := url.Parse(raw); err == nil {
+		byURL = c.fromExtension(u)
+		jsonp = hasJSONPCallback(u)
+	}
+	_, generic := sniffMIMEs[strings.ToLower(strings.TrimSpace(strings.SplitN(mime, ";", 2)[0]))]
+	switch {
+	case byMIME == KindWasm || byURL == KindWasm:
+		return KindWasm, false
+	case rt == network.ResourceTypeScript:
+		// Anything the browser executed as a script counts, whatever the
+		// extension or MIME (JSONP as text/plain, extensionless routes)
+		if byURL != "" {
+			return byURL, false
+		}
+		if byMIME != "" {
+			return byMIME, false
+		}
+		if jsonp && generic {
+			return KindJSONP, false
+		}
+		return KindJavaScript, false
+	case byMIME != "":
+		if byURL != "" && byURL != KindJavaScript {
+			return byURL, false
+		}
+		return byMIME, false
+	case byURL != "":
+		// Trust the extension unless the MIME clearly says it is something
+		// else (HTML error pages, images or stylesheets behind a .js URL)
+		m := strings.ToLower(mime)
+		if strings.HasPrefix(m, "text/html") || strings.HasPrefix(m, "image/") || strings.HasPrefix(m, "text/css") {
+			return "", false
+		}
+		return byURL, false
+	}
+	switch rt {
+	case network.ResourceTypeFetch, network.ResourceTypeXHR, network.ResourceTypeOther:
+		if jsonp && generic {
+			return KindJSONP, false
+		}
+		return "", generic
+	}
+	return "", false
+}
+
+// SniffKind classifies a response body: WebAssembly magic, a JSONP call or
+// common JavaScript constructs. It returns "" for anything else.
+func SniffKind(body []byte) string {
+	if bytes.HasPrefix(body, []byte("\x00asm")) {
+		return KindWasm
+	}
+	if len(body) > sniffLimit {
+		body = body[:sniffLimit]
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] == '<' || trimmed[0] == '{' || trimmed[0] == '[' {
+		return "" // HTML, XML or JSON
+	}
+	if jsonpBody.Match(trimmed) {
+		return KindJSONP
+	}
+	if jsBody.Match(trimmed) {
+		return KindJavaScript
+	}
+	return ""
+}
+
+// responseBody fetches the decoded body of a finished response.
+func responseBody(ctx context.Context, id network.RequestID) ([]byte, error) {
+	var body []byte
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetResponseBody(id).Do(ctx)
+		return err
+	}))
+	return body, err
+}
+
+// sniffResponse fetches a finished response body and classifies it.
+func sniffResponse(ctx context.Context, id network.RequestID) string {
+	body, err := responseBody(ctx, id)
+	if err != nil {
+		return ""
+	}
+	return SniffKind(body)
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestClassify(t *testing.T) {
+	c := NewClassifier([]string{".vue"}, []string{"text/x-custom=module"})
+	cases := []struct {
+		url, mime string
+		rt        network.ResourceType
+		want      string
+		wantSniff bool
+	}{
+		{"https://a.com/app.js", "application/javascript", network.ResourceTypeScript, KindJavaScript, false},
+		{"https://a.com/app.mjs", "text/javascript", network.ResourceTypeScript, KindModule, false},
+		{"https://a.com/route", "", network.ResourceTypeScript, KindJavaScript, false},
+		{"https://a.com/bundle?cb=1699999", "application/javascript", network.ResourceTypeScript, KindJavaScript, false},
+		{"https://a.com/bundle?cb=1699999", "application/javascript", network.ResourceTypeFetch, KindJavaScript, false},
+		{"https://a.com/api?callback=fn", "application/javascript", network.ResourceTypeScript, KindJavaScript, false},
+		{"https://a.com/api?callback=fn", "text/plain", network.ResourceTypeScript, KindJSONP, false},
+		{"https://a.com/api?callback=fn", "", network.ResourceTypeXHR, KindJSONP, false},
+		{"https://a.com/api?callback=fn", "application/json", network.ResourceTypeXHR, "", false},
+		{"https://a.com/api?callback=fn", "application/json", network.ResourceTypeFetch, "", false},
+		{"https://a.com/data.jsonp", "application/javascript", network.ResourceTypeScript, KindJSONP, false},
+		{"https://a.com/app.wasm", "application/octet-stream", network.ResourceTypeFetch, KindWasm, false},
+		{"https://a.com/mod", "application/wasm", network.ResourceTypeFetch, KindWasm, false},
+		{"https://a.com/app.js", "text/html", network.ResourceTypeDocument, "", false},
+		{"https://a.com/app.js", "", network.ResourceTypeFetch, KindJavaScript, false},
+		{"https://a.com/comp.vue", "", network.ResourceTypeFetch, KindJavaScript, false},
+		{"https://a.com/x", "text/x-custom; charset=utf-8", network.ResourceTypeFetch, KindModule, false},
+		{"https://a.com/blob", "text/plain", network.ResourceTypeFetch, "", true},
+		{"https://a.com/blob", "application/json", network.ResourceTypeFetch, "", false},
+		{"https://a.com/img", "", network.ResourceTypeImage, "", false},
+	}
+	for _, tc := range cases {
+		kind, sniff := c.Classify(tc.url, tc.mime, tc.rt)
+		if kind != tc.want || sniff != tc.wantSniff {
+			t.Errorf("Classify(%s, %q, %s) = %q, %v; want %q, %v", tc.url, tc.mime, tc.rt, kind, sniff, tc.want, tc.wantSniff)
+		}
+	}
+}
+
+func TestSniffKind(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"wasm", "\x00asm\x01\x00\x00\x00", KindWasm},
+		{"jsonp", `cb123({"a":1});`, KindJSONP},
+		{"jsonp comment", `/**/ jQuery_1.handle([1,2]);`, KindJSONP},
+		{"function", "function init() { return 1 }", KindJavaScript},
+		{"var", "var a = 1;", KindJavaScript},
+		{"esm", `import { x } from "./x.js";`, KindJavaScript},
+		{"webpack", `(self.webpackChunkapp = self.webpackChunkapp || []).push([[1], {}]);`, KindJavaScript},
+		{"json", `{"a": 1}`, ""},
+		{"json array", `[1, 2]`, ""},
+		{"html", "<!doctype html><html></html>", ""},
+		{"empty", "  \n", ""},
+		{"text", "hello world", ""},
+	}
+	for _, c := range cases {
+		if got := SniffKind([]byte(c.body)); got != c.want {
+			t.Errorf("SniffKind(%s) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// Isolation modes accepted by Options.Isolation. Each isolated unit gets its
+// own incognito-style browser context, so cookies, storage, cache and
+// service workers do not leak between seeds, workers or pages.
+const (
+	IsolationSeed   = "seed"   // one context per seed and the pages reached from it (default)
+	IsolationWorker = "worker" // one context per worker
+	IsolationPage   = "page"   // a fresh context for every page
+	IsolationNone   = "none"   // all tabs share the browser's default context
+)
+
+// isolationKey names the browser context a page belongs to; "" means a
+// throwaway context for this page only.
+func isolationKey(mode string, item FrontierItem, worker int) string {
+	switch strings.ToLower(mode) {
+	case IsolationWorker:
+		return fmt.Sprintf("worker:%d", worker)
+	case IsolationPage:
+		return ""
+	default:
+		return "seed:" + item.Seed
+	}
+}
+
+// browserExec runs fn against the browser rather than a tab, for the
+// browser-wide Target and Storage commands.
+func browserExec(bctx context.Context, fn func(ctx context.Context) error) error {
+	return chromedp.Run(bctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return fn(cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Browser))
+	}))
+}
+
+// createBrowserContext creates an isolated browser context and seeds it
+// with the configured cookies.
+func createBrowserContext(bctx context.Context, cookies []*network.CookieParam) (cdp.BrowserContextID, error) {
+	var id cdp.BrowserContextID
+	err := browserExec(bctx, func(ctx context.Context) error {
+		var err error
+		if id, err = target.CreateBrowserContext().Do(ctx); err != nil {
+			return err
+		}
+		if len(cookies) > 0 {
+			return storage.SetCookies(cookies).WithBrowserContextID(id).Do(ctx)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("create browser context: %w", err)
+	}
+	return id, nil
+}
+
+func disposeBrowserContext(bctx context.Context, id cdp.BrowserContextID) {
+	_ = browserExec(bctx, func(ctx context.Context) error {
+		return target.DisposeBrowserContext(id).Do(ctx)
+	})
+}
+
+// setDefaultCookies puts the configured cookies into the browser's default
+// context, used when isolation is off.
+func setDefaultCookies(bctx context.Context, cookies []*network.CookieParam) error {
+	if len(cookies) == 0 {
+		return nil
+	}
+	return browserExec(bctx, func(ctx context.Context) error {
+		return storage.SetCookies(cookies).Do(ctx)
+	})
+}
+
+// cookieParams expands "name=value" pairs (several may share one entry,
+// separated by ";") into cookies for every allowed host suffix.
+func cookieParams(cookies, hosts []string) []*network.CookieParam {
+	var out []*network.CookieParam
+	for _, entry := range cookies {
+		for _, pair := range strings.Split(entry, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || strings.TrimSpace(name) == "" {
+				continue
+			}
+			for _, h := range hosts {
+				h = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(h)), ".")
+				if h == "" {
+					continue
+				}
+				out = append(out, &network.CookieParam{
+					Name:   strings.TrimSpace(name),
+					Value:  strings.TrimSpace(value),
+					Domain: "." + h,
+					Path:   "/",
+				})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCookieParams(t *testing.T) {
+	cases := []struct {
+		name    string
+		cookies []string
+		hosts   []string
+		want    []string // name=value@domain
+	}{
+		{"none", nil, []string{"a.com"}, nil},
+		{"no hosts", []string{"sid=1"}, nil, nil},
+		{"single", []string{"sid=1"}, []string{"a.com"}, []string{"sid=1@.a.com"}},
+		{"pairs and hosts", []string{"a=1; b = 2", "bad", "=x"}, []string{"A.com", ".b.com", " "},
+			[]string{"a=1@.a.com", "a=1@.b.com", "b=2@.a.com", "b=2@.b.com"}},
+	}
+	for _, c := range cases {
+		var got []string
+		for _, p := range cookieParams(c.cookies, c.hosts) {
+			if p.Path != "/" {
+				t.Errorf("%s: cookie %s has path %q", c.name, p.Name, p.Path)
+			}
+			got = append(got, p.Name+"="+p.Value+"@"+p.Domain)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
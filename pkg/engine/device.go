@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// DeviceProfile is a device a crawl can be run as: its viewport, pixel
+// density, touch support and User-Agent. Sites that ship a separate mobile
+// bundle only load it for a matching profile.
+type DeviceProfile struct {
+	Name      string
+	Width     int64
+	Height    int64
+	Scale     float64 // device pixel ratio
+	Mobile    bool    // mobile viewport (meta viewport, overlay scrollbars)
+	Touch     bool
+	UserAgent string // "" keeps the browser's own
+	Platform  string // navigator.platform to go with UserAgent
+}
+
+// deviceProfiles are the built-in profiles accepted by Options.Devices.
+var deviceProfiles = map[string]DeviceProfile{
+	"desktop": {Name: "desktop", Width: 1920, Height: 1080, Scale: 1},
+	"iphone": {Name: "iphone", Width: 390, Height: 844, Scale: 3, Mobile: true, Touch: true, Platform: "iPhone",
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"},
+	"android": {Name: "android", Width: 412, Height: 915, Scale: 2.625, Mobile: true, Touch: true, Platform: "Linux armv8l",
+		UserAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36"},
+	"tablet": {Name: "tablet", Width: 820, Height: 1180, Scale: 2, Mobile: true, Touch: true, Platform: "iPad",
+		UserAgent: "Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"},
+}
+
+// deviceAliases map other accepted names onto built-in profiles.
+var deviceAliases = map[string]string{"mobile": "iphone", "ipad": "tablet", "pixel": "android"}
+
+// LookupDevice returns the built-in profile called name (case-insensitive).
+func LookupDevice(name string) (DeviceProfile, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := deviceAliases[name]; ok {
+		name = alias
+	}
+	d, ok := deviceProfiles[name]
+	return d, ok
+}
+
+// DeviceNames lists the built-in profile names, sorted.
+func DeviceNames() []string {
+	names := make([]string, 0, len(deviceProfiles))
+	for name := range deviceProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveDevices looks up every profile in names, failing on the first
+// unknown one. No names means a single crawl without emulation.
+func resolveDevices(names []string) ([]DeviceProfile, error) {
+	if len(names) == 0 {
+		return []DeviceProfile{{}}, nil
+	}
+	out := make([]DeviceProfile, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		d, ok := LookupDevice(n)
+		if !ok {
+			return nil, fmt.Errorf("unknown device: %s (use %s)", n, strings.Join(DeviceNames(), "|"))
+		}
+		if _, dup := seen[d.Name]; dup {
+			continue
+		}
+		seen[d.Name] = struct{}{}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// emulate applies the profile's viewport and touch settings to the tab in
+// ctx. The User-Agent is set by the caller together with any override.
+func (d DeviceProfile) emulate(ctx context.Context) error {
+	if d.Name == "" {
+		return nil
+	}
+	return chromedp.Run(ctx,
+		emulation.SetDeviceMetricsOverride(d.Width, d.Height, d.Scale, d.Mobile).
+			WithScreenWidth(d.Width).
+			WithScreenHeight(d.Height),
+		emulation.SetTouchEmulationEnabled(d.Touch).WithMaxTouchPoints(touchPoints(d.Touch)),
+	)
+}
+
+func touchPoints(touch bool) int64 {
+	if touch {
+		return 5
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupDevice(t *testing.T) {
+	cases := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"desktop", "desktop", true},
+		{"iPhone", "iphone", true},
+		{" android ", "android", true},
+		{"tablet", "tablet", true},
+		{"mobile", "iphone", true},
+		{"ipad", "tablet", true},
+		{"Pixel", "android", true},
+		{"watch", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		d, ok := LookupDevice(c.name)
+		if ok != c.wantOK || d.Name != c.want {
+			t.Errorf("LookupDevice(%q) = %q, %v; want %q, %v", c.name, d.Name, ok, c.want, c.wantOK)
+		}
+		if ok && (d.Width <= 0 || d.Height <= 0 || d.Scale <= 0) {
+			t.Errorf("LookupDevice(%q): invalid metrics %+v", c.name, d)
+		}
+	}
+}
+
+func TestResolveDevices(t *testing.T) {
+	cases := []struct {
+		names   []string
+		want    []string
+		wantErr bool
+	}{
+		{names: nil, want: []string{""}},
+		{names: []string{"desktop", "mobile", "iphone"}, want: []string{"desktop", "iphone"}},
+		{names: []string{"desktop", "fridge"}, wantErr: true},
+	}
+	for _, c := range cases {
+		devs, err := resolveDevices(c.names)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("resolveDevices(%v): expected error", c.names)
+			}
+			continue
+		}
+		var got []string
+		for _, d := range devs {
+			got = append(got, d.Name)
+		}
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("resolveDevices(%v) = %v, %v; want %v", c.names, got, err, c.want)
+		}
+	}
+}
+
+func TestHARPageID(t *testing.T) {
+	cases := []struct {
+		dev  DeviceProfile
+		loc  LocaleProfile
+		want string
+	}{
+		{DeviceProfile{}, LocaleProfile{}, "https://a.com/"},
+		{DeviceProfile{Name: "iphone"}, LocaleProfile{}, "https://a.com/ [profile=iphone]"},
+		{DeviceProfile{}, LocaleProfile{Name: "de"}, "https://a.com/ [locale=de]"},
+		{DeviceProfile{Name: "desktop"}, LocaleProfile{Name: "jp"}, "https://a.com/ [profile=desktop locale=jp]"},
+	}
+	for _, c := range cases {
+		if got := harPageID("https://a.com/", c.dev, c.loc); got != c.want {
+			t.Errorf("harPageID(%q, %q) = %q, want %q", c.dev.Name, c.loc.Name, got, c.want)
+		}
+	}
+}
@@ -2,17 +2,20 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/url"
 	"os"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/emulation"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 
+	"github.com/cyinnove/jscout/pkg/har"
 	"github.com/cyinnove/jscout/pkg/model"
 	"github.com/cyinnove/jscout/utils"
 )
@@ -20,24 +23,208 @@ import (
 // Options configure the crawling engine.
 type Options struct {
 	AllowedHosts  []string
+
+	// SeedScopes gives seeds their own allowed hosts (seeds missing from it
+	// use AllowedHosts). All seeds then share the workers and browser pool
+	// but are crawled as independent crawls: MaxPages applies per seed.
+	// SeedConcurrency caps the pages of one seed in progress at once (0 = no
+	// cap), so a large site cannot take every worker.
+	SeedScopes      map[string][]string
+	SeedConcurrency int
+
 	ChromePath    string
 	Headless      bool
 	UserAgent     string
+
+	// Stealth hides the usual headless-Chrome fingerprints: automation
+	// launch flags are dropped, navigator.webdriver, plugins, languages,
+	// window.chrome and the WebGL vendor are patched before page scripts run,
+	// and "HeadlessChrome" is removed from the User-Agent unless UserAgent is
+	// set. Only use it where automated testing is allowed.
+	Stealth bool
+
+	// Browsers is the number of browser processes (or remote connections)
+	// pages are spread over; 0 means 1. A browser that crashes or
+	// disconnects is restarted and its in-flight pages are retried. Browsers
+	// are also recycled after RecycleAfterPages pages or once their process
+	// tree uses more than RecycleRSSMB megabytes (local browsers on Linux);
+	// 0 disables either limit.
+	Browsers          int
+	RecycleAfterPages int
+	RecycleRSSMB      int
+
+	// BlockTypes are the resource types failed before they are sent (see
+	// DefaultBlockTypes, used when nil; "none" blocks no type). BlockURLs
+	// adds URL patterns to block and AllowURLs patterns never to block
+	// ('*' and '?' wildcards, as in the Fetch domain). Stylesheets can be
+	// left out for lazy loaders that depend on layout.
+	BlockTypes []string
+	BlockURLs  []string
+	AllowURLs  []string
+
+	// Devices runs the crawl once per named device profile (see
+	// LookupDevice: desktop, iphone, android, tablet), emulating its
+	// viewport, pixel density, touch and User-Agent, and tags every record
+	// and page with the profile. UserAgent, when set, wins over the
+	// profile's. No devices crawls once without emulation.
+	Devices []string
+
+	// Locales repeats the crawl once per locale profile (see LookupLocale),
+	// combined with every device, setting Accept-Language,
+	// navigator.language, Intl, the timezone and the geolocation it names,
+	// and tags records and pages with its Name. No locales leaves them as
+	// the browser has them.
+	Locales []LocaleProfile
+
+	// Isolation gives each seed (IsolationSeed, the default), worker
+	// (IsolationWorker) or page (IsolationPage) its own browser context, or
+	// shares the default one (IsolationNone). Cookies ("name=value") are set
+	// for every host suffix in scope of the context's seed (SeedScopes), or
+	// of AllowedHosts for contexts shared by seeds, and Headers are sent
+	// with every request.
+	Isolation string
+	Cookies   []string
+	Headers   map[string]string
+
+	// TabReuse lets each worker keep one tab for up to this many pages,
+	// resetting it to about:blank in between, instead of opening a new tab
+	// per page; 0 or 1 opens a tab per page. Tabs are never reused across
+	// browser contexts, so IsolationPage always gets fresh tabs.
+	// BenchmarkTabReuse compares the throughput of both.
+	TabReuse int
+
+	// RemoteURL drives an already-running Chrome through its DevTools
+	// endpoint (see ResolveDevToolsURL) instead of launching one; ChromePath
+	// and Headless are ignored then.
+	RemoteURL string
+
 	PageTimeout   time.Duration
 	WaitAfterLoad time.Duration
+
+	// WaitUntil is the wait strategy deciding when a page has loaded (see
+	// ParseWaitStrategy; default networkidle0). WaitAfterLoad caps the wait
+	// after the load event and again after the page has been interacted
+	// with.
+	WaitUntil string
+
 	MaxDepth      int
 	MaxPages      int
 	Concurrency   int
+
+	// SPARoutes hooks the History API and reads SPA router tables so that
+	// client-side routes are crawled alongside a[href] links.
+	SPARoutes bool
+
+	// URLPolicy controls how JS URLs are normalized for JSURL and dedupe:
+	// utils.URLPolicyStrip (default), URLPolicyCacheBust or URLPolicyKeep.
+	// RawURL always keeps the URL as requested.
+	URLPolicy string
+
+	// Classifier decides which responses are scripts (nil uses the default
+	// rules). Sniff inspects bodies of generic fetch/XHR responses
+	// (text/plain, octet-stream) to catch JSONP, extensionless JS and WASM.
+	Classifier *Classifier
+	Sniff      bool
+
+	// HAR records every request of each page load as HTTP Archive entries;
+	// HARBodies also captures script and document response bodies.
+	HAR       bool
+	HARBodies bool
+
+	// Initiators enables JavaScript stack traces on request initiators so
+	// each JS record names the script and position that loaded it.
+	Initiators bool
+
+	// Explore clicks buttons, tabs and other listener-bearing elements to
+	// trigger lazy chunks, skipping anything matching ExploreDeny.
+	Explore           bool
+	ExploreMaxActions int
+	ExploreDeny       []string
+
+	// FillForms fills visible form fields with dummy data; SubmitForms also
+	// submits in-scope forms that do not match FormDeny.
+	FillForms   bool
+	SubmitForms bool
+	FormDeny    []string
+
+	// Strategy selects the frontier order: "bfs" (default), "dfs" or
+	// "score"; Crawl rejects anything else. Score overrides the default
+	// scorer of the score strategy.
+	Strategy string
+	Score    ScoreFunc
+
+	// OnPage, when set, is called as soon as each page has been visited
+	// with its record and JS records (nil for failed pages), so results can
+	// be streamed before Crawl returns. Calls are serialized.
+	OnPage func(page *model.PageRecord, js []*model.JSRecord)
 }
 
 type Engine struct {
-	opt Options
+	opt   Options
+	pages    []*model.PageRecord
+	har      *har.Log
+	restarts int
 }
 
 func New(opt Options) *Engine { return &Engine{opt: opt} }
 
-// Crawl runs a scoped crawl starting from seeds and returns discovered JS records.
+// Crawl runs a scoped crawl starting from seeds and returns discovered JS
+// records. With several Options.Devices or Options.Locales the crawl is
+// repeated once per device and locale and the results of all of them are
+// returned.
 func (e *Engine) Crawl(seeds []string) ([]*model.JSRecord, error) {
+	if _, err := ParseWaitStrategy(e.opt.WaitUntil); err != nil {
+		return nil, err
+	}
+	if _, err := NewFrontier(e.opt.Strategy, e.opt.Score); err != nil {
+		return nil, err
+	}
+	devices, err := resolveDevices(e.opt.Devices)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := newBlockPolicy(e.opt); err != nil {
+		return nil, err
+	}
+	for _, loc := range e.opt.Locales {
+		if err := loc.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	locales := e.opt.Locales
+	if len(locales) == 0 {
+		locales = []LocaleProfile{{}}
+	}
+
+	var all []*model.JSRecord
+	var pages []*model.PageRecord
+	var harLog *har.Log
+	if e.opt.HAR {
+		harLog = har.NewLog()
+	}
+	restarts := 0
+	for _, dev := range devices {
+		for _, loc := range locales {
+			results, err := e.crawl(seeds, dev, loc)
+			all = append(all, results...)
+			pages = append(pages, e.pages...)
+			if harLog != nil {
+				harLog.Append(e.har)
+			}
+			restarts += e.restarts
+			if err != nil {
+				e.pages, e.har, e.restarts = pages, harLog, restarts
+				return all, err
+			}
+		}
+	}
+	e.pages, e.har, e.restarts = pages, harLog, restarts
+	return all, nil
+}
+
+// crawl is one crawl of seeds as device dev from locale loc (no emulation
+// for zero profiles).
+func (e *Engine) crawl(seeds []string, dev DeviceProfile, loc LocaleProfile) ([]*model.JSRecord, error) {
 	rootCtx := context.Background()
 
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
@@ -76,59 +263,137 @@ func (e *Engine) Crawl(seeds []string) ([]*model.JSRecord, error) {
 	if v := os.Getenv("JSCOUT_NO_SANDBOX"); v == "1" || v == "true" || v == "TRUE" {
 		opts = append(opts, chromedp.Flag("no-sandbox", true))
 	}
+	if e.opt.Stealth {
+		opts = append(opts, stealthFlags(e.opt.Headless)...)
+	}
 	if e.opt.ChromePath != "" {
 		opts = append(opts, chromedp.ExecPath(e.opt.ChromePath))
 	}
 
-	alloc, cancelAlloc := chromedp.NewExecAllocator(rootCtx, opts...)
-	defer cancelAlloc()
-	browserCtx, cancelBrowser := chromedp.NewContext(alloc)
-	defer cancelBrowser()
-
-	type qitem struct {
-		u     string
-		depth int
+	pool, err := newBrowserPool(rootCtx, e.opt, opts)
+	if err != nil {
+		return nil, err
 	}
+	defer pool.close()
 
-	jobs := make(chan qitem, 256)
-	var wg sync.WaitGroup
+	frontier, err := NewFrontier(e.opt.Strategy, e.opt.Score)
+	if err != nil {
+		return nil, err
+	}
 
 	visited := make(map[string]struct{})
 	seen := make(map[string]struct{})
+	jsSeen := make(map[string]struct{}) // JS URLs across the whole crawl, for ParentNewJS
+	retries := make(map[string]int)     // pages re-queued after their browser died
 	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+	inflight := 0
 
 	results := make([]*model.JSRecord, 0, 256)
+	pages := make([]*model.PageRecord, 0, 64)
+	var harLog *har.Log
+	if e.opt.HAR {
+		harLog = har.NewLog()
+	}
 	var resMu sync.Mutex
+	var onPageMu sync.Mutex
 
-	var processed int32
-	maxPages := e.opt.MaxPages
-	if maxPages < 0 {
-		maxPages = 0
+	// With SeedScopes every seed is its own crawl sharing the browser pool,
+	// so MaxPages counts per seed; otherwise it counts for the whole crawl.
+	// budget is guarded by mu.
+	budget := newPageBudget(e.opt.MaxPages, len(e.opt.SeedScopes) > 0)
+	seedInflight := make(map[string]int) // pages in progress per seed, guarded by mu
+	var held []FrontierItem              // popped while their seed was at SeedConcurrency or MaxPages
+	seedHasRoom := func(seed string) bool {
+		return e.opt.SeedConcurrency <= 0 || seedInflight[seed] < e.opt.SeedConcurrency
+	}
+	underLimit := func(item FrontierItem) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return budget.left(item)
 	}
 
 	// Seed queue
-	enqueue := func(u string, d int) {
+	enqueue := func(item FrontierItem) {
+		if pu, err := url.Parse(item.URL); err == nil {
+			item.URL = normalizePageURL(pu)
+		}
 		mu.Lock()
-		if _, ok := seen[u]; ok {
-			mu.Unlock()
+		defer mu.Unlock()
+		if _, ok := seen[item.URL]; ok {
 			return
 		}
-		seen[u] = struct{}{}
+		seen[item.URL] = struct{}{}
+		frontier.Push(item)
+		cond.Signal()
+	}
+
+	// next blocks until an item is available or the crawl has drained.
+	// Items of seeds at their SeedConcurrency limit or out of page budget
+	// wait in held, in case a taken page is given back. A returned item
+	// counts against the page limit until it is given back.
+	next := func() (FrontierItem, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		take := func(item FrontierItem) (FrontierItem, bool) {
+			inflight++
+			seedInflight[item.Seed]++
+			budget.take(item)
+			return item, true
+		}
+		for {
+			for i, item := range held {
+				if budget.left(item) && seedHasRoom(item.Seed) {
+					held = append(held[:i], held[i+1:]...)
+					return take(item)
+				}
+			}
+			for frontier.Len() > 0 {
+				item, _ := frontier.Pop()
+				if budget.left(item) && seedHasRoom(item.Seed) {
+					return take(item)
+				}
+				held = append(held, item)
+			}
+			if inflight == 0 {
+				return FrontierItem{}, false
+			}
+			cond.Wait()
+		}
+	}
+	// requeue puts back a page whose browser died under it
+	requeue := func(item FrontierItem) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if retries[item.URL] >= maxPageRetries {
+			return false
+		}
+		retries[item.URL]++
+		delete(visited, item.URL)
+		budget.giveBack(item)
+		frontier.Push(item)
+		return true
+	}
+	var fatalErr error
+	finish := func(item FrontierItem) {
+		mu.Lock()
+		inflight--
+		seedInflight[item.Seed]--
+		mu.Unlock()
+		cond.Broadcast()
+	}
+	// skip finishes an item that was taken but not crawled
+	skip := func(item FrontierItem) {
+		mu.Lock()
+		budget.giveBack(item)
 		mu.Unlock()
-		wg.Add(1)
-		jobs <- qitem{u: u, depth: d}
+		finish(item)
 	}
 
 	for _, s := range seeds {
-		enqueue(s, 0)
+		enqueue(FrontierItem{URL: s, Seed: s})
 	}
 
-	// Closer goroutine
-	go func() {
-		wg.Wait()
-		close(jobs)
-	}()
-
 	// Workers
 	workers := e.opt.Concurrency
 	if workers <= 0 {
@@ -136,204 +401,454 @@ func (e *Engine) Crawl(seeds []string) ([]*model.JSRecord, error) {
 	}
 	var wwg sync.WaitGroup
 	wwg.Add(workers)
+	isolate := !strings.EqualFold(e.opt.Isolation, IsolationNone)
 	for i := 0; i < workers; i++ {
+		worker := i
 		go func() {
 			defer wwg.Done()
-			for item := range jobs {
-				// Page limit
-				if maxPages > 0 && atomic.LoadInt32(&processed) >= int32(maxPages) {
-					wg.Done()
-					continue
+			var tab *workerTab
+			defer func() {
+				if tab != nil {
+					tab.close()
+				}
+			}()
+			for {
+				item, ok := next()
+				if !ok {
+					return
 				}
 
 				// Scope gate & visited
-				pu, err := url.Parse(item.u)
-				if err != nil || !utils.HostInScope(pu, e.opt.AllowedHosts) {
-					wg.Done()
+				scope := e.scopeOf(item.Seed)
+				pu, err := url.Parse(item.URL)
+				if err != nil || !utils.HostInScope(pu, scope) {
+					skip(item)
 					continue
 				}
 				mu.Lock()
-				if _, ok := visited[item.u]; ok {
+				if _, ok := visited[item.URL]; ok {
 					mu.Unlock()
-					wg.Done()
+					skip(item)
 					continue
 				}
-				visited[item.u] = struct{}{}
+				visited[item.URL] = struct{}{}
 				mu.Unlock()
 
-				// Tab context with timeout
-				tabCtx, tabCancel := chromedp.NewContext(browserCtx)
-				ctx, cancel := context.WithTimeout(tabCtx, e.opt.PageTimeout)
-				// Run collection
-				js, links, err := collectJSOnPage(ctx, item.u, e.opt.WaitAfterLoad, e.opt.UserAgent)
-				cancel()
-				tabCancel()
+				var prefer *pooledBrowser
+				if tab != nil {
+					prefer = tab.b
+				}
+				b, err := pool.acquire(prefer)
+				if err != nil {
+					// Every browser is gone; stop this worker
+					mu.Lock()
+					fatalErr = err
+					mu.Unlock()
+					skip(item)
+					return
+				}
+
+				// Tab context with timeout, in the page's isolated browser context
+				var res *pageResult
+				var bcID cdp.BrowserContextID
+				key := "default"
+				if isolate {
+					key = isolationKey(e.opt.Isolation, item, worker)
+					// Cookies go to the seed's own hosts, except in a
+					// worker's context, which serves every seed
+					cookieHosts := scope
+					if strings.EqualFold(e.opt.Isolation, IsolationWorker) {
+						cookieHosts = e.opt.AllowedHosts
+					}
+					bcID, err = pool.browserContext(b, key, cookieHosts)
+				}
+				if err != nil {
+					res = failedPage(item.URL, err)
+				} else {
+					// Reuse the worker's tab when it lives in the right browser and context
+					reuse := e.opt.TabReuse > 1 && key != ""
+					if tab != nil && (!reuse || !tab.usableFor(b, key)) {
+						tab.close()
+						tab = nil
+					}
+					if tab == nil {
+						tab = openTab(b, key, bcID)
+					}
+					ctx, cancel := context.WithTimeout(tab.ctx, e.opt.PageTimeout)
+					// Run collection
+					pageOpt := e.opt
+					pageOpt.AllowedHosts = scope
+					res, err = collectJSOnPage(ctx, item.URL, pageOpt, dev, loc)
+					cancel()
+					tab.uses++
+					if !reuse || tab.uses >= e.opt.TabReuse || !tab.reset() {
+						tab.close()
+						tab = nil
+					}
+					if bcID != "" && key == "" {
+						pool.dropContext(b, bcID)
+					}
+				}
+				if pool.release(b, err) && requeue(item) {
+					finish(item)
+					continue
+				}
+
+				res.Page.Depth = item.Depth
+				res.Page.Parent = item.Parent
+				res.Page.Seed = item.Seed
+				res.Page.Profile, res.Page.Locale = dev.Name, loc.Name
+				for _, r := range res.JS {
+					r.Profile, r.Locale = dev.Name, loc.Name
+				}
+				resMu.Lock()
+				pages = append(pages, res.Page)
+				if harLog != nil {
+					harLog.Append(res.HAR)
+				}
+				resMu.Unlock()
 
 				if err == nil {
+					js, links := res.JS, res.Links
 					resMu.Lock()
 					results = append(results, js...)
 					resMu.Unlock()
 
-					// Enqueue links if within depth and within scope
-					if item.depth < e.opt.MaxDepth {
-						for _, l := range links {
-							lu, err := url.Parse(l)
-							if err != nil {
-								continue
-							}
-							if utils.HostInScope(lu, e.opt.AllowedHosts) {
-								// Respect page limit at enqueue time to reduce pressure
-								if maxPages == 0 || atomic.LoadInt32(&processed) < int32(maxPages) {
-									enqueue(lu.String(), item.depth+1)
-								}
-							}
+					newJS := 0
+					mu.Lock()
+					for _, r := range js {
+						if _, ok := jsSeen[r.JSURL]; !ok {
+							jsSeen[r.JSURL] = struct{}{}
+							newJS++
+						}
+					}
+					mu.Unlock()
+
+					// Record in-scope links and enqueue them if within depth
+					linked := make(map[string]struct{}, len(links))
+					for _, l := range links {
+						lu, err := url.Parse(l)
+						if err != nil || !utils.HostInScope(lu, scope) {
+							continue
+						}
+						nl := normalizePageURL(lu)
+						if _, ok := linked[nl]; !ok {
+							linked[nl] = struct{}{}
+							res.Page.Links = append(res.Page.Links, nl)
+						}
+						// Respect page limit at enqueue time to reduce pressure
+						if item.Depth < e.opt.MaxDepth && underLimit(item) {
+							enqueue(FrontierItem{URL: nl, Depth: item.Depth + 1, Parent: item.URL, Seed: item.Seed, ParentNewJS: newJS})
 						}
 					}
 				}
 
-				atomic.AddInt32(&processed, 1)
-				wg.Done()
+				if e.opt.OnPage != nil {
+					var js []*model.JSRecord
+					if err == nil {
+						js = res.JS
+					}
+					onPageMu.Lock()
+					e.opt.OnPage(res.Page, js)
+					onPageMu.Unlock()
+				}
+
+				finish(item)
 			}
 		}()
 	}
 
 	wwg.Wait()
+	e.pages = pages
+	e.har = harLog
+	e.restarts = pool.restarted()
+	if fatalErr != nil {
+		return results, fmt.Errorf("browser pool: %w", fatalErr)
+	}
 	return results, nil
 }
 
-// collectJSOnPage visits a URL and returns JS resources and discovered links.
-func collectJSOnPage(ctx context.Context, pageURL string, waitAfterLoad time.Duration, userAgent string) ([]*model.JSRecord, []string, error) {
+// scopeOf returns the allowed hosts for pages reached from seed.
+func (e *Engine) scopeOf(seed string) []string {
+	if s, ok := e.opt.SeedScopes[seed]; ok {
+		return s
+	}
+	return e.opt.AllowedHosts
+}
+
+// Pages returns a record for every page visited by the last Crawl.
+func (e *Engine) Pages() []*model.PageRecord { return e.pages }
+
+// BrowserRestarts returns how many browsers the last Crawl restarted after a
+// crash or for recycling.
+func (e *Engine) BrowserRestarts() int { return e.restarts }
+
+// HAR returns the HTTP Archive of the last Crawl, or nil unless Options.HAR is set.
+func (e *Engine) HAR() *har.Log { return e.har }
+
+// collectJSOnPage visits a URL and returns JS resources, discovered links and
+// a page record. The page record is returned even when the visit fails.
+func collectJSOnPage(ctx context.Context, pageURL string, opt Options, dev DeviceProfile, loc LocaleProfile) (*pageResult, error) {
+	waitAfterLoad := opt.WaitAfterLoad
+	userAgent := opt.UserAgent
+
+	started := time.Now()
+	page := &model.PageRecord{URL: pageURL}
+	res := &pageResult{Page: page}
+	var harRec *harRecorder
+	if opt.HAR {
+		harRec = newHARRecorder(harPageID(pageURL, dev, loc), opt.HARBodies)
+	}
+	fail := func(err error) (*pageResult, error) {
+		page.ErrorKind, page.ErrorCode = classifyError(err)
+		page.Error = err.Error()
+		page.TotalMS = time.Since(started).Milliseconds()
+		if harRec != nil {
+			res.HAR = harRec.finish(page)
+		}
+		return res, err
+	}
+	wait, err := ParseWaitStrategy(opt.WaitUntil)
+	if err != nil {
+		return fail(err)
+	}
+
 	if err := chromedp.Run(ctx, network.Enable()); err != nil {
-		return nil, nil, err
+		return fail(err)
 	}
+	if len(opt.Headers) > 0 {
+		headers := make(network.Headers, len(opt.Headers))
+		for k, v := range opt.Headers {
+			headers[k] = v
+		}
+		if err := chromedp.Run(ctx, network.SetExtraHTTPHeaders(headers)); err != nil {
+			return fail(err)
+		}
+	}
+	mainFrame := cdp.FrameID(chromedp.FromContext(ctx).Target.TargetID)
 
-	// Block non-JS resources using network.setBlockedURLs
-	// Block common non-JS resource patterns to speed up loading
-	blockedPatterns := []string{
-		"*.jpg", "*.jpeg", "*.png", "*.gif", "*.webp", "*.svg", "*.ico", "*.bmp", // Images
-		"*.css", "*.woff", "*.woff2", "*.ttf", "*.otf", "*.eot",                    // CSS and fonts
-		"*.mp4", "*.mp3", "*.webm", "*.ogg", "*.wav", "*.avi",                      // Media
-		"*.pdf", "*.zip", "*.tar", "*.gz",                                          // Documents/archives
-		"*.xml", "*.rss", "*.atom",                                                 // Feeds
-		"data:image/*", "data:font/*",                                              // Data URIs for images/fonts
+	if opt.Initiators {
+		_ = enableInitiatorStacks(ctx)
+	}
+	if harRec != nil {
+		chromedp.ListenTarget(ctx, func(ev interface{}) { harRec.handle(ctx, ev) })
+	}
+	initiators := make(map[network.RequestID]*network.Initiator)
+	requestStart := make(map[network.RequestID]*cdp.MonotonicTime)
+	recByID := make(map[network.RequestID]*model.JSRecord) // observed JS awaiting size/timing
+
+	if err := dev.emulate(ctx); err != nil {
+		return fail(err)
+	}
+	if err := loc.emulate(ctx); err != nil {
+		return fail(err)
+	}
+	if userAgent == "" {
+		userAgent = dev.UserAgent
+	}
+	if opt.Stealth {
+		if id, err := installStealth(ctx, loc); err == nil {
+			defer removeScriptOnClose(ctx, id)
+		}
+		if userAgent == "" {
+			userAgent = stealthUserAgent(ctx)
+		}
+	}
+	if userAgent == "" && loc.Locale != "" {
+		// Accept-Language rides on the User-Agent override
+		userAgent = browserUserAgent(ctx)
 	}
-	
-	// Set blocked URLs (this blocks requests matching these patterns)
-	_ = chromedp.Run(ctx, network.SetBlockedURLs(blockedPatterns))
+
+	// Record client-side route changes from the very first script onwards
+	if opt.SPARoutes {
+		if id, err := installRouteHooks(ctx); err == nil {
+			defer removeScriptOnClose(ctx, id)
+		}
+	}
+
+	// Fail requests for resources a JS crawl does not need
+	blocking, err := newBlockPolicy(opt)
+	if err != nil {
+		return fail(err)
+	}
+	blockedCount, unblock, err := blocking.install(ctx, mainFrame)
+	if err != nil {
+		return fail(err)
+	}
+	defer unblock()
 
 	// Track JS responses from network events
 	records := make([]*model.JSRecord, 0, 16)
 	seenURLs := make(map[string]struct{})
 	var mu sync.Mutex
+	trigger := "" // action that caused subsequent loads, set during exploration
 	
-	// Helper function to remove query parameters and fragments from URL
-	cleanURL := func(urlStr string) string {
-		// Remove query parameters and fragments
-		return strings.Split(strings.Split(urlStr, "#")[0], "?")[0]
+	// Normalized key for JSURL and deduplication
+	normalize := func(urlStr string) string {
+		return utils.NormalizeJSURL(urlStr, opt.URLPolicy)
 	}
-	
-	// Helper function to check if URL is a JavaScript file
-	isJavaScriptFile := func(urlStr string, mimeType string) bool {
-		// Remove query parameters and fragments
-		urlWithoutQuery := cleanURL(urlStr)
-		
-		// Check MIME type first
-		jsMimeTypes := []string{
-			"application/javascript",
-			"text/javascript",
-			"application/x-javascript",
-			"application/ecmascript",
-			"text/ecmascript",
+
+	cls := opt.Classifier
+	if cls == nil {
+		cls = NewClassifier(nil, nil)
+	}
+
+	// addObserved records a fetched script once per normalized URL; callers hold mu
+	addObserved := func(id network.RequestID, resp *network.Response, kind, trig string) *model.JSRecord {
+		cleanJsURL := normalize(resp.URL)
+		if _, exists := seenURLs[cleanJsURL]; exists {
+			return nil
 		}
-		for _, mime := range jsMimeTypes {
-			if strings.HasPrefix(mimeType, mime) {
-				return true
-			}
+		seenURLs[cleanJsURL] = struct{}{}
+		rec := &model.JSRecord{
+			JSURL:        cleanJsURL,
+			RawURL:       strings.SplitN(resp.URL, "#", 2)[0],
+			SourcePage:   pageURL,
+			Status:       resp.Status,
+			MIME:         resp.MimeType,
+			FromCache:    resp.FromDiskCache || resp.FromPrefetchCache || resp.FromServiceWorker,
+			Observation:  model.Observed,
+			ResourceKind: kind,
+			Trigger:      trig,
+			Headers:      pickHeaders(resp.Headers),
 		}
-		
-		// Check file extension - must end with .js
-		if strings.HasSuffix(strings.ToLower(urlWithoutQuery), ".js") {
-			return true
+		if t := resp.Timing; t != nil {
+			rec.TTFBMS = t.ReceiveHeadersEnd
 		}
-		
-		return false
+		applyInitiator(rec, initiators[id])
+		records = append(records, rec)
+		return rec
 	}
-	
+
+	// Responses whose kind only the body can tell, sniffed once loaded
+	type sniffCandidate struct {
+		resp    *network.Response
+		trigger string
+	}
+	pendingSniff := make(map[network.RequestID]sniffCandidate)
+	var sniffWG sync.WaitGroup
+
 	chromedp.ListenTarget(ctx, func(ev interface{}) {
 		if recv, ok := ev.(*network.EventResponseReceived); ok {
 			if recv.Response != nil {
-				url := recv.Response.URL
-				mimeType := recv.Response.MimeType
-				
-				// Only capture verified JS files
-				// Always verify to ensure it's actually a .js file (not SVG, CSS, images, etc.)
-				isJS := isJavaScriptFile(url, mimeType)
-				
-				if isJS {
-					mu.Lock()
-					// Clean URL (remove query params and fragments) for storage and deduplication
-					cleanJsURL := cleanURL(url)
-					if _, exists := seenURLs[cleanJsURL]; !exists {
-						seenURLs[cleanJsURL] = struct{}{}
-						rec := &model.JSRecord{
-							JSURL:      cleanJsURL, // Store without query params
-							SourcePage: pageURL,
-							Status:     recv.Response.Status,
-							MIME:       mimeType,
-							FromCache:  recv.Response.FromDiskCache || recv.Response.FromPrefetchCache || recv.Response.FromServiceWorker,
-						}
-						records = append(records, rec)
+				// Classify by resource type, MIME and extension rules
+				kind, sniff := cls.Classify(recv.Response.URL, recv.Response.MimeType, recv.Type)
+				mu.Lock()
+				if kind != "" {
+					if rec := addObserved(recv.RequestID, recv.Response, kind, trigger); rec != nil {
+						recByID[recv.RequestID] = rec
 					}
-					mu.Unlock()
+				} else if sniff && opt.Sniff {
+					pendingSniff[recv.RequestID] = sniffCandidate{resp: recv.Response, trigger: trigger}
 				}
+				mu.Unlock()
 			}
 		}
 	})
 
-	// Track network requests for idle detection
-	pendingRequests := make(map[string]bool)
-	var pendingMu sync.Mutex
-	networkIdleTimeout := 500 * time.Millisecond // Consider idle if no requests for this duration
-	
-	// Track when requests start
+	// Track the main document response and its redirect chain
+	navDone := false
 	chromedp.ListenTarget(ctx, func(ev interface{}) {
-		if req, ok := ev.(*network.EventRequestWillBeSent); ok {
-			if req.Request != nil {
-				pendingMu.Lock()
-				pendingRequests[req.RequestID.String()] = true
-				pendingMu.Unlock()
+		switch e := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			mu.Lock()
+			if e.Initiator != nil {
+				initiators[e.RequestID] = e.Initiator
+			}
+			if _, ok := requestStart[e.RequestID]; !ok {
+				requestStart[e.RequestID] = e.Timestamp
+			}
+			mu.Unlock()
+			if e.Type == network.ResourceTypeDocument && e.FrameID == mainFrame && e.RedirectResponse != nil && e.Request != nil {
+				mu.Lock()
+				if !navDone {
+					page.Redirects = append(page.Redirects, e.Request.URL)
+				}
+				mu.Unlock()
+			}
+		case *network.EventDataReceived:
+			mu.Lock()
+			if rec, ok := recByID[e.RequestID]; ok {
+				rec.DecodedSize += e.DataLength
+			}
+			mu.Unlock()
+		case *network.EventLoadingFinished:
+			mu.Lock()
+			var duration float64
+			if st := requestStart[e.RequestID]; st != nil && e.Timestamp != nil {
+				duration = float64(e.Timestamp.Time().Sub(st.Time()).Microseconds()) / 1000
+			}
+			if rec, ok := recByID[e.RequestID]; ok {
+				rec.EncodedSize = int64(e.EncodedDataLength)
+				rec.DurationMS = duration
+				delete(recByID, e.RequestID)
+			}
+			if cand, ok := pendingSniff[e.RequestID]; ok {
+				delete(pendingSniff, e.RequestID)
+				// Fetching the body is a CDP round trip; never block the event loop
+				sniffWG.Add(1)
+				go func(id network.RequestID, encoded int64) {
+					defer sniffWG.Done()
+					kind := sniffResponse(ctx, id)
+					if kind == "" {
+						return
+					}
+					mu.Lock()
+					if rec := addObserved(id, cand.resp, kind, cand.trigger); rec != nil {
+						rec.EncodedSize = encoded
+						rec.DurationMS = duration
+					}
+					mu.Unlock()
+				}(e.RequestID, int64(e.EncodedDataLength))
+			}
+			mu.Unlock()
+		case *network.EventResponseReceived:
+			if e.Type == network.ResourceTypeDocument && e.FrameID == mainFrame && e.Response != nil {
+				mu.Lock()
+				if !navDone {
+					page.Status = e.Response.Status
+				}
+				mu.Unlock()
 			}
-		}
-		// Track when requests finish
-		if fin, ok := ev.(*network.EventLoadingFinished); ok {
-			pendingMu.Lock()
-			delete(pendingRequests, fin.RequestID.String())
-			pendingMu.Unlock()
-		}
-		// Track failed requests too
-		if failed, ok := ev.(*network.EventLoadingFailed); ok {
-			pendingMu.Lock()
-			delete(pendingRequests, failed.RequestID.String())
-			pendingMu.Unlock()
 		}
 	})
 
+	// Track requests in flight for idle detection
+	idle := newIdleTracker(ctx)
+
 	tasks := chromedp.Tasks{}
 	if userAgent != "" {
-		tasks = append(tasks, emulation.SetUserAgentOverride(userAgent))
+		override := emulation.SetUserAgentOverride(userAgent)
+		if dev.Platform != "" && opt.UserAgent == "" {
+			override = override.WithPlatform(dev.Platform)
+		}
+		if lang := loc.acceptLanguage(); lang != "" {
+			override = override.WithAcceptLanguage(lang)
+		}
+		tasks = append(tasks, override)
 	}
-	tasks = append(tasks,
-		chromedp.Navigate(pageURL),
-		chromedp.WaitReady("body", chromedp.ByQuery),
-	)
 	if err := chromedp.Run(ctx, tasks); err != nil {
-		return nil, nil, err
+		return fail(err)
 	}
-
-	// Wait for initial page load to complete (network idle)
-	if waitAfterLoad > 0 {
-		waitForNetworkIdle(ctx, &pendingRequests, &pendingMu, waitAfterLoad, networkIdleTimeout)
+	if err := wait.navigate(ctx, pageURL); err != nil {
+		return fail(err)
+	}
+	if err := chromedp.Run(ctx, chromedp.WaitReady("body", chromedp.ByQuery)); err != nil {
+		return fail(err)
 	}
+	mu.Lock()
+	navDone = true
+	mu.Unlock()
+	page.LoadMS = time.Since(started).Milliseconds()
+	_ = chromedp.Run(ctx,
+		chromedp.Evaluate(`document.title`, &page.Title),
+		chromedp.Evaluate(`location.href`, &page.FinalURL),
+	)
+
+	// Wait for the page to settle as the wait strategy asks
+	wait.settle(ctx, idle, waitAfterLoad)
+	page.IdleMS = time.Since(started).Milliseconds() - page.LoadMS
+	page.Challenge = detectChallenge(ctx)
 
 	// Interact with the page to trigger lazy-loaded JS files
 	_ = chromedp.Run(ctx, chromedp.EvaluateAsDevTools(`
@@ -359,45 +874,87 @@ func collectJSOnPage(ctx context.Context, pageURL string, waitAfterLoad time.Dur
 	`, nil))
 
 	// Wait for network to be idle after interactions (with timeout)
-	waitForNetworkIdle(ctx, &pendingRequests, &pendingMu, 5*time.Second, networkIdleTimeout)
+	if waitAfterLoad > 0 {
+		idle.wait(ctx, waitAfterLoad, wait.allowedInflight())
+	}
+
+	setTrigger := func(t string) {
+		mu.Lock()
+		trigger = t
+		mu.Unlock()
+	}
+	waitIdle := func(maxWait time.Duration) {
+		idle.wait(ctx, maxWait, wait.allowedInflight())
+	}
+
+	// Click through safe UI elements to trigger code-split chunks
+	var exploredLinks []string
+	if opt.Explore && opt.ExploreMaxActions > 0 {
+		exploredLinks = explorePage(ctx, pageURL, opt, setTrigger,
+			func() int {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(records)
+			},
+			waitIdle,
+		)
+	}
+
+	// Interact with forms to load validation, wizard and upload code
+	if opt.FillForms {
+		exploredLinks = append(exploredLinks, exploreForms(ctx, pageURL, opt, setTrigger, waitIdle)...)
+	}
 
 	// Extract JS files from multiple sources: script tags, preload links, and HTML source
 	var allJSURLs []string
-	_ = chromedp.Run(ctx, chromedp.EvaluateAsDevTools(`
+	extsJSON, _ := json.Marshal(cls.Extensions())
+	_ = chromedp.Run(ctx, chromedp.EvaluateAsDevTools(strings.Replace(`
 		(function() {
 			const jsURLs = new Set();
 			const baseURL = window.location.href;
 			const origin = window.location.origin;
 			
-			// Helper to clean URL (remove query params and fragments)
+			// Helper to clean URL (remove query params and fragments) for the suffix check
 			function cleanURL(urlStr) {
 				return urlStr.split('?')[0].split('#')[0];
 			}
 			
+			// Script extensions known to the classifier (.js, .mjs, .cjs, .wasm, ...)
+			const jsExts = __JSCOUT_EXTS__;
+
+			function isHTTP(urlStr) {
+				return !!urlStr && urlStr.startsWith('http');
+			}
+
 			// Helper to check if URL is a JavaScript file
 			function isJSFile(urlStr) {
-				if (!urlStr || !urlStr.startsWith('http')) return false;
+				if (!isHTTP(urlStr)) return false;
 				// Remove query params and fragments
 				const urlWithoutQuery = cleanURL(urlStr).toLowerCase();
-				// Must end with .js
-				return urlWithoutQuery.endsWith('.js');
+				return jsExts.some(ext => urlWithoutQuery.endsWith(ext));
+			}
+
+			// Script elements are scripts whatever their extension, unless typed as data/templates
+			function isScriptElement(s) {
+				return !s.type || /^(module|text\/javascript|application\/javascript|text\/ecmascript|application\/ecmascript)$/i.test(s.type);
 			}
 			
 			// Extract from script tags
 			Array.from(document.querySelectorAll('script[src]')).forEach(s => {
 				try {
 					const url = new URL(s.src, baseURL).href;
-					if (isJSFile(url)) jsURLs.add(cleanURL(url));
+					if (isScriptElement(s) && isHTTP(url)) jsURLs.add(url.split('#')[0]);
 				} catch(e) {}
 			});
 			
-			// Extract from preload/prefetch link tags (only if as="script" or ends with .js)
+			// Extract from preload/prefetch link tags (any URL if as="script"/modulepreload, else by extension)
 			Array.from(document.querySelectorAll('link[rel="preload"], link[rel="prefetch"], link[rel="modulepreload"]')).forEach(link => {
 				const href = link.href;
-				if (link.as === 'script' || isJSFile(href)) {
+				const scriptHint = link.as === 'script' || link.rel === 'modulepreload';
+				if (scriptHint || isJSFile(href)) {
 					try {
 						const url = new URL(href, baseURL).href;
-						if (isJSFile(url)) jsURLs.add(cleanURL(url));
+						if (scriptHint ? isHTTP(url) : isJSFile(url)) jsURLs.add(url.split('#')[0]);
 					} catch(e) {}
 				}
 			});
@@ -405,21 +962,21 @@ func collectJSOnPage(ctx context.Context, pageURL string, waitAfterLoad time.Dur
 			// Extract from HTML source (for embedded script references)
 			try {
 				const html = document.documentElement.outerHTML;
-				// Look for script src patterns - must end with .js
-				const scriptSrcRegex = /src=["']([^"']+\.js[^"']*)["']/gi;
+				// Look for src patterns; isJSFile keeps known script extensions
+				const scriptSrcRegex = /src=["']([^"']+)["']/gi;
 				let match;
 				while ((match = scriptSrcRegex.exec(html)) !== null) {
 					try {
 						const url = new URL(match[1], baseURL).href;
-						if (isJSFile(url)) jsURLs.add(cleanURL(url));
+						if (isJSFile(url)) jsURLs.add(url.split('#')[0]);
 					} catch(e) {}
 				}
-				// Look for href patterns in link tags - must end with .js
-				const linkHrefRegex = /<link[^>]+href=["']([^"']+\.js[^"']*)["']/gi;
+				// Look for href patterns in link tags with known script extensions
+				const linkHrefRegex = /<link[^>]+href=["']([^"']+)["']/gi;
 				while ((match = linkHrefRegex.exec(html)) !== null) {
 					try {
 						const url = new URL(match[1], baseURL).href;
-						if (isJSFile(url)) jsURLs.add(cleanURL(url));
+						if (isJSFile(url)) jsURLs.add(url.split('#')[0]);
 					} catch(e) {}
 				}
 			} catch(e) {}
@@ -428,10 +985,10 @@ func collectJSOnPage(ctx context.Context, pageURL string, waitAfterLoad time.Dur
 			try {
 				const allScripts = document.querySelectorAll('script');
 				allScripts.forEach(script => {
-					if (script.src) {
+					if (script.src && isScriptElement(script)) {
 						try {
 							const url = new URL(script.src, baseURL).href;
-							if (isJSFile(url)) jsURLs.add(cleanURL(url));
+							if (isHTTP(url)) jsURLs.add(url.split('#')[0]);
 						} catch(e) {}
 					}
 				});
@@ -439,66 +996,58 @@ func collectJSOnPage(ctx context.Context, pageURL string, waitAfterLoad time.Dur
 			
 			return Array.from(jsURLs);
 		})()
-	`, &allJSURLs))
+	`, "__JSCOUT_EXTS__", string(extsJSON), 1), &allJSURLs))
 	
-	// Add all discovered scripts that weren't captured by network events
-	// Filter to ensure only .js files are added
+	// Add all discovered scripts that weren't captured by network events.
+	// The DOM script only returns script elements, script preloads and URLs
+	// with known extensions, so unknown extensions are plain JavaScript.
+	sniffWG.Wait()
 	mu.Lock()
 	for _, jsURL := range allJSURLs {
-		// URLs from DOM extraction are already cleaned, but double-check
-		cleanJsURL := cleanURL(jsURL)
-		
-		// Double-check it's actually a JS file
-		if !strings.HasSuffix(strings.ToLower(cleanJsURL), ".js") {
-			continue // Skip non-JS files
+		kind := cls.FromURL(jsURL)
+		if kind == "" {
+			kind = KindJavaScript
 		}
-		
+
+		// URLs from DOM extraction keep their query; normalize per policy
+		cleanJsURL := normalize(jsURL)
+
 		if _, exists := seenURLs[cleanJsURL]; !exists {
 			seenURLs[cleanJsURL] = struct{}{}
+			// Never fetched while we watched: no status or MIME to report
 			rec := &model.JSRecord{
-				JSURL:      cleanJsURL, // Store without query params
-				SourcePage: pageURL,
-				Status:     200, // Assume success if referenced
-				MIME:       "application/javascript",
-				FromCache:  false,
+				JSURL:        cleanJsURL,
+				RawURL:       jsURL,
+				SourcePage:   pageURL,
+				Observation:  model.ReferencedOnly,
+				ResourceKind: kind,
 			}
 			records = append(records, rec)
 		}
 	}
 	mu.Unlock()
 
+	var attrs []scriptAttrs
+	_ = chromedp.Run(ctx, chromedp.Evaluate(scriptAttrsScript, &attrs))
+	mu.Lock()
+	applyScriptAttrs(records, attrs, normalize)
+	mu.Unlock()
+
 	var links []string
 	_ = chromedp.Run(ctx, chromedp.EvaluateAsDevTools(`Array.from(document.querySelectorAll('a[href]')).map(a => a.href)`, &links))
-	return records, links, nil
-}
-
-// waitForNetworkIdle waits for network to be idle (no pending requests) or timeout
-// It checks if there are no pending requests for idleDuration, up to maxWait total time
-func waitForNetworkIdle(ctx context.Context, pendingRequests *map[string]bool, mu *sync.Mutex, maxWait time.Duration, idleDuration time.Duration) {
-	deadline := time.Now().Add(maxWait)
-	idleStart := time.Time{} // When we first detected idle state
-	
-	for time.Now().Before(deadline) {
-		mu.Lock()
-		pendingCount := len(*pendingRequests)
-		mu.Unlock()
-		
-		if pendingCount == 0 {
-			// No pending requests
-			if idleStart.IsZero() {
-				// First time we see idle, mark the start
-				idleStart = time.Now()
-			} else if time.Since(idleStart) >= idleDuration {
-				// We've been idle long enough
-				return
-			}
-		} else {
-			// There are pending requests, reset idle timer
-			idleStart = time.Time{}
-		}
-		
-		// Check every 100ms for responsiveness
-		time.Sleep(100 * time.Millisecond)
+	links = append(links, exploredLinks...)
+	if opt.SPARoutes {
+		links = append(links, collectRoutes(ctx)...)
+	}
+	page.Forms = formTargets(ctx, opt.AllowedHosts)
+	page.JSCount = len(records)
+	page.Blocked = blockedCount()
+	page.TotalMS = time.Since(started).Milliseconds()
+	if harRec != nil {
+		res.HAR = harRec.finish(page)
 	}
-	// Timeout reached - proceed anyway
+	res.JS = records
+	res.Links = links
+	return res, nil
 }
+
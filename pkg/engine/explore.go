@@ -0,0 +1,291 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/domdebugger"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/cyinnove/logify"
+)
+
+// DefaultExploreDenyList holds words and CSS selectors of elements that must
+// never be clicked during exploration because they are likely to change
+// server-side state (sessions, data, payments). Text entries match whole
+// words of an element's label, so "pay" does not deny "display".
+var DefaultExploreDenyList = []string{
+	"logout", "log out", "signout", "sign out", "sign-out",
+	"delete", "remove", "destroy", "deactivate", "unsubscribe",
+	"pay", "purchase", "checkout", "buy", "order", "subscribe",
+	"submit", "send", "confirm", "transfer", "reset",
+	"[type=submit]", "a[href*=logout]", "a[href*=signout]",
+}
+
+const (
+	// exploreIdleWait bounds how long we wait for lazy chunks after one click.
+	exploreIdleWait = 2 * time.Second
+	// listenerScanLimit bounds the number of generic elements inspected with
+	// DOMDebugger.getEventListeners, which costs one round trip per element.
+	listenerScanLimit = 300
+)
+
+// denyRule is one deny-list entry as exploreEnumerateScript applies it: as a
+// CSS selector, and as a whole-word pattern over the element's text.
+type denyRule struct {
+	Selector string `json:"sel"`
+	Pattern  string `json:"re"`
+}
+
+// denyPattern returns a case-insensitive regexp source, valid in both Go and
+// JavaScript, matching fragment as whole words of an element's text.
+func denyPattern(fragment string) string {
+	return `(^|[^a-z0-9])` + regexp.QuoteMeta(strings.ToLower(strings.TrimSpace(fragment))) + `($|[^a-z0-9])`
+}
+
+// clickable describes one exploration candidate returned by exploreEnumerateScript.
+type clickable struct {
+	Index int    `json:"index"`
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// exploreEnumerateScript lists visible clickable elements that are not on the
+// deny-list, tagging each with data-jscout-idx so it can be clicked later.
+// Plain navigation links and form submit buttons are left to the crawler and
+// the form explorer respectively. camelCase ids are split into words before
+// deny rules are matched. %s is replaced with the JSON list of denyRules.
+const exploreEnumerateScript = `
+(function(deny) {
+	const sel = 'button, [role=button], [role=tab], [role=menuitem], [role=switch], summary, ' +
+		'[aria-haspopup], [aria-expanded], [data-toggle], [data-bs-toggle], [onclick], [data-jscout-click]';
+	const visible = (el) => {
+		const r = el.getBoundingClientRect();
+		if (r.width === 0 || r.height === 0) return false;
+		const cs = getComputedStyle(el);
+		return cs.visibility !== 'hidden' && cs.display !== 'none';
+	};
+	const text = (el) => [el.innerText, el.getAttribute('aria-label'), el.getAttribute('title'),
+		el.getAttribute('value'), el.id, el.getAttribute('name')].filter(Boolean).join(' ')
+		.replace(/([a-z])([A-Z])/g, '$1 $2').toLowerCase();
+	const rules = deny.map(d => ({ sel: d.sel, re: new RegExp(d.re, 'i') }));
+	const denied = (el) => {
+		const t = text(el);
+		return rules.some(d => {
+			try { if (el.matches(d.sel) || el.closest(d.sel)) return true; } catch(e) {}
+			return d.re.test(t);
+		});
+	};
+	const path = (el) => {
+		const parts = [];
+		for (let cur = el; cur && cur.nodeType === 1 && parts.length < 8; cur = cur.parentElement) {
+			let i = 1;
+			for (let sib = cur.previousElementSibling; sib; sib = sib.previousElementSibling) {
+				if (sib.tagName === cur.tagName) i++;
+			}
+			parts.unshift(cur.tagName.toLowerCase() + ':' + i);
+		}
+		return parts.join('>');
+	};
+	const out = [];
+	document.querySelectorAll('[data-jscout-idx]').forEach(el => el.removeAttribute('data-jscout-idx'));
+	Array.from(document.querySelectorAll(sel)).forEach(el => {
+		if (el.disabled || !visible(el) || denied(el)) return;
+		const a = el.closest('a[href]');
+		if (a) {
+			const h = a.getAttribute('href') || '';
+			if (h && h !== '#' && !h.startsWith('javascript:')) return;
+		}
+		if (el.form && (el.type === 'submit' || el.type === 'image')) return;
+		const idx = out.length;
+		el.setAttribute('data-jscout-idx', String(idx));
+		const label = el.tagName.toLowerCase() + ' "' + (el.innerText || el.getAttribute('aria-label') || '').trim().replace(/\s+/g, ' ').slice(0, 40) + '"';
+		out.push({ index: idx, key: path(el) + '|' + label, label: label });
+	});
+	return out;
+})(%s)
+`
+
+// exploreClickScript clicks a tagged element with a full pointer/mouse event
+// sequence, since many UI libraries react to mousedown/pointerdown rather
+// than click. window.open is neutralised so popups become recorded routes.
+const exploreClickScript = `
+(function(idx) {
+	if (!window.__jscoutOpenPatched) {
+		window.__jscoutOpenPatched = true;
+		window.open = function(u) {
+			try { if (window.__jscoutRoutes) window.__jscoutRoutes.add(new URL(String(u), location.href).href); } catch(e) {}
+			return null;
+		};
+	}
+	const el = document.querySelector('[data-jscout-idx="' + idx + '"]');
+	if (!el) return false;
+	el.scrollIntoView({ block: 'center' });
+	const opts = { bubbles: true, cancelable: true, view: window };
+	['pointerdown', 'mousedown', 'pointerup', 'mouseup'].forEach(t => {
+		try {
+			el.dispatchEvent(t.startsWith('pointer') ? new PointerEvent(t, opts) : new MouseEvent(t, opts));
+		} catch(e) {}
+	});
+	el.click();
+	return true;
+})(%d)
+`
+
+// exploreDismissScript closes modals, menus and popovers opened by a click.
+// Only widgets listening for Escape close this way; any other change a click
+// leaves behind is caught by exploreFingerprintScript.
+const exploreDismissScript = `
+(function() {
+	const ev = { key: 'Escape', code: 'Escape', keyCode: 27, bubbles: true, cancelable: true };
+	const t = document.activeElement || document.body;
+	t.dispatchEvent(new KeyboardEvent('keydown', ev));
+	t.dispatchEvent(new KeyboardEvent('keyup', ev));
+	if (document.activeElement && document.activeElement.blur) document.activeElement.blur();
+	return true;
+})()
+`
+
+// exploreFingerprintScript summarises the body markup so explorePage can
+// tell whether an action left the DOM changed.
+const exploreFingerprintScript = `
+(function() {
+	const s = document.body ? document.body.innerHTML : '';
+	let h = 0;
+	for (let i = 0; i < s.length; i++) h = (h * 31 + s.charCodeAt(i)) | 0;
+	return s.length + ':' + h;
+})()
+`
+
+// markListenerTargets tags generic elements that have click-like listeners
+// attached (found through DOMDebugger.getEventListeners) with
+// data-jscout-click so exploreEnumerateScript picks them up.
+func markListenerTargets(ctx context.Context) {
+	const group = "jscout-explore"
+	_ = chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		defer func() { _ = runtime.ReleaseObjectGroup(group).Do(ctx) }()
+
+		expr := fmt.Sprintf(`Array.from(document.querySelectorAll('div, span, li, img, svg, i, label, td, p, h1, h2, h3, h4')).slice(0, %d)`, listenerScanLimit)
+		arr, exc, err := runtime.Evaluate(expr).WithObjectGroup(group).Do(ctx)
+		if err != nil || exc != nil || arr == nil || arr.ObjectID == "" {
+			return err
+		}
+		props, _, _, _, err := runtime.GetProperties(arr.ObjectID).WithOwnProperties(true).Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, p := range props {
+			if _, err := strconv.Atoi(p.Name); err != nil || p.Value == nil || p.Value.ObjectID == "" {
+				continue
+			}
+			listeners, err := domdebugger.GetEventListeners(p.Value.ObjectID).Do(ctx)
+			if err != nil {
+				continue
+			}
+			for _, l := range listeners {
+				switch l.Type {
+				case "click", "mousedown", "mouseup", "pointerdown", "pointerup":
+					_, _, _ = runtime.CallFunctionOn(`function() { this.setAttribute('data-jscout-click', '1'); }`).
+						WithObjectID(p.Value.ObjectID).Do(ctx)
+				default:
+					continue
+				}
+				break
+			}
+		}
+		return nil
+	}))
+}
+
+// explorePage clicks up to opt.ExploreMaxActions safe elements on the page,
+// attributing JS loaded after each click via setTrigger. When a click
+// navigates away the new location is returned as a discovered link; when it
+// navigates or leaves the DOM changed after dismissal, the original page is
+// loaded again so every action starts from the same state.
+func explorePage(ctx context.Context, pageURL string, opt Options, setTrigger func(string), countJS func() int, waitIdle func(time.Duration)) []string {
+	deny := make([]denyRule, 0, len(opt.ExploreDeny))
+	for _, d := range opt.ExploreDeny {
+		if strings.TrimSpace(d) != "" {
+			deny = append(deny, denyRule{Selector: d, Pattern: denyPattern(d)})
+		}
+	}
+	denyJSON, err := json.Marshal(deny)
+	if err != nil {
+		return nil
+	}
+
+	var startHref string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`location.href`, &startHref)); err != nil {
+		return nil
+	}
+
+	markListenerTargets(ctx)
+
+	var links []string
+	done := make(map[string]struct{})
+	for actions := 0; actions < opt.ExploreMaxActions; actions++ {
+		if ctx.Err() != nil {
+			break
+		}
+		var cands []clickable
+		if err := chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(exploreEnumerateScript, denyJSON), &cands)); err != nil {
+			break
+		}
+		var next *clickable
+		for i := range cands {
+			if _, ok := done[cands[i].Key]; !ok {
+				next = &cands[i]
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		done[next.Key] = struct{}{}
+
+		var fpBefore string
+		_ = chromedp.Run(ctx, chromedp.Evaluate(exploreFingerprintScript, &fpBefore))
+		before := countJS()
+		setTrigger("click " + next.Label)
+		var clicked bool
+		_ = chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(exploreClickScript, next.Index), &clicked))
+		waitIdle(exploreIdleWait)
+		setTrigger("")
+		if n := countJS() - before; n > 0 {
+			logify.Debugf("Explore %s: click %s loaded %d new JS", pageURL, next.Label, n)
+		}
+
+		var href string
+		if err := chromedp.Run(ctx, chromedp.Evaluate(`location.href`, &href)); err != nil {
+			break
+		}
+		if href != startHref {
+			links = append(links, href)
+		} else {
+			_ = chromedp.Run(ctx, chromedp.Evaluate(exploreDismissScript, nil))
+			var fpAfter string
+			if err := chromedp.Run(ctx, chromedp.Evaluate(exploreFingerprintScript, &fpAfter)); err != nil {
+				break
+			}
+			if fpAfter == fpBefore {
+				continue
+			}
+		}
+		// Restore the original page so later actions see the same state
+		if err := chromedp.Run(ctx,
+			chromedp.Navigate(pageURL),
+			chromedp.WaitReady("body", chromedp.ByQuery),
+		); err != nil {
+			break
+		}
+		waitIdle(exploreIdleWait)
+		_ = chromedp.Run(ctx, chromedp.Evaluate(`location.href`, &startHref))
+		markListenerTargets(ctx)
+	}
+	return links
+}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDenyPattern(t *testing.T) {
+	cases := []struct {
+		fragment, text string
+		want           bool
+	}{
+		{"pay", "pay now", true},
+		{"pay", "display settings", false},
+		{"pay", "paypal", false},
+		{"order", "place order", true},
+		{"order", "border toggle", false},
+		{"log out", "log out of account", true},
+		{"logout", "logout-btn", true},
+		{"signout", "nav_signout", true},
+		{"sign-out", "sign-out", true},
+		{"Delete", "delete item", true},
+		{"delete", "undeleted", false},
+		{"submit", "submit", true},
+	}
+	for _, c := range cases {
+		re := regexp.MustCompile("(?i)" + denyPattern(c.fragment))
+		if got := re.MatchString(c.text); got != c.want {
+			t.Errorf("denyPattern(%q) on %q = %v, want %v", c.fragment, c.text, got, c.want)
+		}
+	}
+}
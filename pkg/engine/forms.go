@@ -0,0 +1,251 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/cyinnove/logify"
+
+	"github.com/cyinnove/jscout/utils"
+)
+
+// DefaultFormDenyList holds words of form actions, ids, names and button
+// labels whose forms are never submitted, even when submission is enabled.
+// Entries match whole words, so "delete" does not deny "undeleted".
+var DefaultFormDenyList = []string{
+	"logout", "signout", "sign out", "delete", "remove", "destroy", "deactivate",
+	"unsubscribe", "pay", "payment", "purchase", "checkout", "order", "billing",
+	"transfer", "withdraw", "password", "invite", "contact", "feedback", "comment",
+}
+
+// formSubmitLimit bounds how many forms are submitted per page.
+const formSubmitLimit = 5
+
+// formFillScript fills every visible, editable field with type-appropriate
+// dummy data. Values are set through the native setter and followed by
+// focus/input/change/blur events so framework-controlled inputs (React,
+// Vue, Angular) observe them and load their validation or widget code.
+const formFillScript = `
+(function() {
+	const setValue = (el, v) => {
+		const proto = el instanceof HTMLTextAreaElement ? HTMLTextAreaElement.prototype :
+			el instanceof HTMLSelectElement ? HTMLSelectElement.prototype : HTMLInputElement.prototype;
+		const desc = Object.getOwnPropertyDescriptor(proto, 'value');
+		if (desc && desc.set) desc.set.call(el, v); else el.value = v;
+	};
+	const hint = (el) => [el.name, el.id, el.placeholder, el.getAttribute('autocomplete'),
+		el.getAttribute('aria-label')].filter(Boolean).join(' ').toLowerCase();
+	const textFor = (el) => {
+		const h = hint(el);
+		if (/mail/.test(h)) return 'jscout@example.com';
+		if (/phone|tel|mobile/.test(h)) return '5555550100';
+		if (/zip|postal/.test(h)) return '10001';
+		if (/url|website|site/.test(h)) return 'https://example.com';
+		if (/name/.test(h)) return 'Jscout Test';
+		if (/year/.test(h)) return '2024';
+		return 'test';
+	};
+	const valueFor = (el) => {
+		switch ((el.type || '').toLowerCase()) {
+			case 'email': return 'jscout@example.com';
+			case 'number': case 'range': return el.min || '1';
+			case 'date': return '2024-01-15';
+			case 'datetime-local': return '2024-01-15T10:30';
+			case 'month': return '2024-01';
+			case 'week': return '2024-W03';
+			case 'time': return '10:30';
+			case 'tel': return '5555550100';
+			case 'url': return 'https://example.com';
+			case 'password': return 'Jscout-Test-123!';
+			case 'color': return '#336699';
+			default: return textFor(el);
+		}
+	};
+	const visible = (el) => {
+		const r = el.getBoundingClientRect();
+		return r.width > 0 && r.height > 0 && getComputedStyle(el).visibility !== 'hidden';
+	};
+	let filled = 0;
+	const radios = new Set();
+	document.querySelectorAll('input, textarea, select').forEach(el => {
+		if (el.disabled || el.readOnly || !visible(el)) return;
+		const type = (el.type || '').toLowerCase();
+		if (['hidden', 'file', 'submit', 'button', 'reset', 'image'].includes(type)) return;
+		try {
+			el.focus();
+			el.dispatchEvent(new FocusEvent('focusin', { bubbles: true }));
+			if (type === 'checkbox') {
+				if (!el.checked) el.click();
+			} else if (type === 'radio') {
+				if (radios.has(el.name)) return;
+				radios.add(el.name);
+				if (!el.checked) el.click();
+			} else if (el instanceof HTMLSelectElement) {
+				if (el.options.length > 1) setValue(el, el.options[1].value);
+			} else {
+				setValue(el, valueFor(el));
+			}
+			el.dispatchEvent(new Event('input', { bubbles: true }));
+			el.dispatchEvent(new Event('change', { bubbles: true }));
+			el.blur();
+			el.dispatchEvent(new FocusEvent('focusout', { bubbles: true }));
+			filled++;
+		} catch(e) {}
+	});
+	return filled;
+})()
+`
+
+// pageForm describes one form returned by formListScript.
+type pageForm struct {
+	Index  int    `json:"index"`
+	Action string `json:"action"`
+	Method string `json:"method"`
+	Text   string `json:"text"`
+}
+
+// formListScript lists forms with their resolved action and identifying text.
+const formListScript = `
+(function() {
+	return Array.from(document.forms).map((f, i) => {
+		const buttons = Array.from(f.querySelectorAll('button, input[type=submit]'))
+			.map(b => b.innerText || b.value || '').join(' ');
+		return {
+			index: i,
+			action: f.action || location.href,
+			method: (f.method || 'get').toLowerCase(),
+			text: [f.id, f.name, f.className, f.getAttribute('aria-label'), buttons].filter(Boolean).join(' ').toLowerCase(),
+		};
+	});
+})()
+`
+
+// formTargets returns the distinct in-scope action URLs of the forms on the
+// page in ctx, without fragments.
+func formTargets(ctx context.Context, scope []string) []string {
+	var forms []pageForm
+	if err := chromedp.Run(ctx, chromedp.Evaluate(formListScript, &forms)); err != nil {
+		return nil
+	}
+	var out []string
+	seen := make(map[string]struct{}, len(forms))
+	for _, f := range forms {
+		u, err := url.Parse(f.Action)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !utils.HostInScope(u, scope) {
+			continue
+		}
+		u.Fragment, u.RawFragment = "", ""
+		target := u.String()
+		if _, ok := seen[target]; ok {
+			continue
+		}
+		seen[target] = struct{}{}
+		out = append(out, target)
+	}
+	return out
+}
+
+// formSubmitScript submits a form the way a user would, so submit handlers
+// and HTML validation run before the browser navigates.
+const formSubmitScript = `
+(function(i) {
+	const f = document.forms[i];
+	if (!f) return false;
+	if (typeof f.requestSubmit === 'function') f.requestSubmit(); else f.submit();
+	return true;
+})(%d)
+`
+
+// formDenyRegexps compiles each non-empty deny-list entry once, matching it
+// as whole words like the exploration deny-list does.
+func formDenyRegexps(deny []string) []*regexp.Regexp {
+	var out []*regexp.Regexp
+	for _, d := range deny {
+		if strings.TrimSpace(d) != "" {
+			out = append(out, regexp.MustCompile("(?i)"+denyPattern(d)))
+		}
+	}
+	return out
+}
+
+// formDenied reports whether a form's action or text matches any deny rule.
+func formDenied(f pageForm, deny []*regexp.Regexp) bool {
+	hay := f.Action + " " + f.Text
+	for _, re := range deny {
+		if re.MatchString(hay) {
+			return true
+		}
+	}
+	return false
+}
+
+// exploreForms fills all visible fields on the page and, when opt.SubmitForms
+// is set, submits up to formSubmitLimit in-scope forms not on opt.FormDeny.
+// Locations reached by submission are returned as discovered links.
+func exploreForms(ctx context.Context, pageURL string, opt Options, setTrigger func(string), waitIdle func(time.Duration)) []string {
+	fill := func() {
+		setTrigger("form fill")
+		var filled int
+		_ = chromedp.Run(ctx, chromedp.Evaluate(formFillScript, &filled))
+		if filled > 0 {
+			waitIdle(exploreIdleWait)
+		}
+		setTrigger("")
+	}
+	fill()
+	if !opt.SubmitForms {
+		return nil
+	}
+
+	var forms []pageForm
+	if err := chromedp.Run(ctx, chromedp.Evaluate(formListScript, &forms)); err != nil {
+		return nil
+	}
+
+	deny := formDenyRegexps(opt.FormDeny)
+	var links []string
+	submitted := 0
+	for _, f := range forms {
+		if submitted >= formSubmitLimit || ctx.Err() != nil {
+			break
+		}
+		au, err := url.Parse(f.Action)
+		if err != nil || !utils.HostInScope(au, opt.AllowedHosts) {
+			continue
+		}
+		if formDenied(f, deny) {
+			logify.Debugf("Skipping denied form %s %s on %s", strings.ToUpper(f.Method), f.Action, pageURL)
+			continue
+		}
+		submitted++
+		logify.Infof("Submitting form %s %s on %s", strings.ToUpper(f.Method), f.Action, pageURL)
+
+		setTrigger("submit " + f.Action)
+		_ = chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(formSubmitScript, f.Index), nil))
+		waitIdle(exploreIdleWait)
+		setTrigger("")
+
+		var href string
+		if err := chromedp.Run(ctx, chromedp.Evaluate(`location.href`, &href)); err != nil {
+			break
+		}
+		if href != pageURL {
+			links = append(links, href)
+		}
+		// Reload so the next form starts from a clean page, then refill
+		if err := chromedp.Run(ctx,
+			chromedp.Navigate(pageURL),
+			chromedp.WaitReady("body", chromedp.ByQuery),
+		); err != nil {
+			break
+		}
+		waitIdle(exploreIdleWait)
+		fill()
+	}
+	return links
+}
@@ -0,0 +1,28 @@
+package engine
+
+import "testing"
+
+func TestFormDenied(t *testing.T) {
+	cases := []struct {
+		form pageForm
+		deny []string
+		want bool
+	}{
+		{pageForm{Action: "https://a.com/search", Text: "search go"}, DefaultFormDenyList, false},
+		{pageForm{Action: "https://a.com/account/delete", Text: "confirm"}, DefaultFormDenyList, true},
+		{pageForm{Action: "https://a.com/", Text: "newsletter unsubscribe"}, DefaultFormDenyList, true},
+		{pageForm{Action: "https://a.com/Checkout", Text: ""}, DefaultFormDenyList, true},
+		{pageForm{Action: "https://a.com/login", Text: "email password sign in"}, DefaultFormDenyList, true},
+		{pageForm{Action: "https://a.com/filter", Text: "apply"}, []string{" APPLY "}, true},
+		{pageForm{Action: "https://a.com/filter", Text: "apply"}, []string{"", " "}, false},
+		{pageForm{Action: "https://a.com/filter", Text: "apply"}, nil, false},
+		{pageForm{Action: "https://a.com/mail", Text: "show undeleted"}, DefaultFormDenyList, false},
+		{pageForm{Action: "https://a.com/deleted-items", Text: "search"}, DefaultFormDenyList, false},
+		{pageForm{Action: "https://a.com/settings", Text: "display"}, DefaultFormDenyList, false},
+	}
+	for _, c := range cases {
+		if got := formDenied(c.form, formDenyRegexps(c.deny)); got != c.want {
+			t.Errorf("formDenied(%s %q) = %v, want %v", c.form.Action, c.form.Text, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package engine
+
+import (
+	"container/heap"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FrontierItem is a page waiting to be crawled.
+type FrontierItem struct {
+	URL         string
+	Depth       int
+	Parent      string // page the URL was discovered on; empty for seeds
+	Seed        string // seed the page was reached from
+	ParentNewJS int    // JS files first seen on the parent page
+	Score       float64
+}
+
+// Frontier orders pending pages. Implementations do not need to be safe for
+// concurrent use; the engine serialises all calls.
+type Frontier interface {
+	Push(item FrontierItem)
+	Pop() (FrontierItem, bool)
+	Len() int
+}
+
+// ScoreFunc ranks a page for the score strategy; higher scores are crawled
+// first. It is called with the engine's frontier lock held, so it may keep
+// state between calls but must not block.
+type ScoreFunc func(item FrontierItem) float64
+
+// Frontier strategies accepted by Options.Strategy.
+const (
+	StrategyBFS   = "bfs"
+	StrategyDFS   = "dfs"
+	StrategyScore = "score"
+)
+
+// NewFrontier returns the frontier for a strategy name. An empty name selects
+// the score strategy when score is non-nil and BFS otherwise. For the score
+// strategy a nil score uses NewDefaultScorer. Unknown names are an error.
+func NewFrontier(strategy string, score ScoreFunc) (Frontier, error) {
+	switch strings.ToLower(strategy) {
+	case StrategyBFS:
+		return &queueFrontier{}, nil
+	case StrategyDFS:
+		return &stackFrontier{}, nil
+	case StrategyScore:
+		if score == nil {
+			score = NewDefaultScorer()
+		}
+		return &priorityFrontier{score: score}, nil
+	case "":
+		if score != nil {
+			return &priorityFrontier{score: score}, nil
+		}
+		return &queueFrontier{}, nil
+	}
+	return nil, fmt.Errorf("unknown strategy: %s (use bfs|dfs|score)", strategy)
+}
+
+// queueFrontier is a FIFO queue (breadth-first).
+type queueFrontier struct {
+	items []FrontierItem
+	head  int
+}
+
+func (f *queueFrontier) Push(item FrontierItem) { f.items = append(f.items, item) }
+
+func (f *queueFrontier) Pop() (FrontierItem, bool) {
+	if f.head >= len(f.items) {
+		return FrontierItem{}, false
+	}
+	item := f.items[f.head]
+	f.items[f.head] = FrontierItem{}
+	f.head++
+	// Reclaim the consumed prefix once it dominates the slice
+	if f.head > 1024 && f.head*2 > len(f.items) {
+		f.items = append([]FrontierItem(nil), f.items[f.head:]...)
+		f.head = 0
+	}
+	return item, true
+}
+
+func (f *queueFrontier) Len() int { return len(f.items) - f.head }
+
+// stackFrontier is a LIFO stack (depth-first).
+type stackFrontier struct {
+	items []FrontierItem
+}
+
+func (f *stackFrontier) Push(item FrontierItem) { f.items = append(f.items, item) }
+
+func (f *stackFrontier) Pop() (FrontierItem, bool) {
+	if len(f.items) == 0 {
+		return FrontierItem{}, false
+	}
+	item := f.items[len(f.items)-1]
+	f.items = f.items[:len(f.items)-1]
+	return item, true
+}
+
+func (f *stackFrontier) Len() int { return len(f.items) }
+
+// priorityFrontier pops the highest scored item, FIFO among equal scores.
+type priorityFrontier struct {
+	score ScoreFunc
+	h     scoredHeap
+	seq   uint64
+}
+
+func (f *priorityFrontier) Push(item FrontierItem) {
+	item.Score = f.score(item)
+	f.seq++
+	heap.Push(&f.h, scoredItem{item: item, seq: f.seq})
+}
+
+func (f *priorityFrontier) Pop() (FrontierItem, bool) {
+	if f.h.Len() == 0 {
+		return FrontierItem{}, false
+	}
+	return heap.Pop(&f.h).(scoredItem).item, true
+}
+
+func (f *priorityFrontier) Len() int { return f.h.Len() }
+
+type scoredItem struct {
+	item FrontierItem
+	seq  uint64
+}
+
+type scoredHeap []scoredItem
+
+func (h scoredHeap) Len() int { return len(h) }
+func (h scoredHeap) Less(i, j int) bool {
+	if h[i].item.Score != h[j].item.Score {
+		return h[i].item.Score > h[j].item.Score
+	}
+	return h[i].seq < h[j].seq
+}
+func (h scoredHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *scoredHeap) Push(x any)   { *h = append(*h, x.(scoredItem)) }
+func (h *scoredHeap) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	*h = old[:n-1]
+	return it
+}
+
+var (
+	// appAreaSegments are path segments that usually lead to authenticated or
+	// feature-rich parts of an application.
+	appAreaSegments = map[string]struct{}{
+		"app": {}, "apps": {}, "dashboard": {}, "admin": {}, "settings": {}, "account": {},
+		"console": {}, "portal": {}, "manage": {}, "internal": {}, "profile": {}, "billing": {},
+		"workspace": {}, "projects": {}, "user": {}, "users": {}, "debug": {}, "staff": {},
+	}
+	// lowValueSegments are boilerplate pages that rarely load app code.
+	lowValueSegments = map[string]struct{}{
+		"privacy": {}, "terms": {}, "legal": {}, "cookies": {}, "cookie-policy": {}, "tos": {},
+		"careers": {}, "jobs": {}, "press": {}, "blog": {}, "news": {}, "about": {}, "imprint": {},
+		"sitemap": {}, "accessibility": {}, "contact": {}, "help": {}, "faq": {},
+	}
+
+	numericSegment = regexp.MustCompile(`^\d+$`)
+	idSegment      = regexp.MustCompile(`^(?:[0-9a-fA-F]{8,}|[0-9a-fA-F-]{32,36}|[A-Za-z0-9_-]{20,})$`)
+)
+
+// URLPattern reduces a URL to its shape (host, path with ids replaced,
+// sorted query keys) so that /item/1 and /item/2 count as the same pattern.
+func URLPattern(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, s := range segs {
+		switch {
+		case numericSegment.MatchString(s):
+			segs[i] = "{n}"
+		case idSegment.MatchString(s):
+			segs[i] = "{id}"
+		}
+	}
+	keys := make([]string, 0, len(u.Query()))
+	for k := range u.Query() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	p := strings.ToLower(u.Host) + "/" + strings.Join(segs, "/")
+	if len(keys) > 0 {
+		p += "?" + strings.Join(keys, "&")
+	}
+	if isHashRoute(u.Fragment) {
+		p += "#" + u.Fragment
+	}
+	return p
+}
+
+// NewDefaultScorer returns the default ScoreFunc. It favours app-like areas
+// (/app, /dashboard, /admin, /settings, ...), URL patterns not queued before,
+// and children of pages that produced new JS, and demotes boilerplate pages
+// and deeper levels.
+func NewDefaultScorer() ScoreFunc {
+	patterns := make(map[string]int)
+	return func(item FrontierItem) float64 {
+		score := 0.0
+
+		u, err := url.Parse(item.URL)
+		if err == nil {
+			path := strings.ToLower(u.Path)
+			if isHashRoute(u.Fragment) {
+				path += "/" + strings.ToLower(strings.TrimLeft(u.Fragment, "!"))
+			}
+			for _, seg := range strings.Split(path, "/") {
+				if _, ok := appAreaSegments[seg]; ok {
+					score += 3
+				}
+				if _, ok := lowValueSegments[seg]; ok {
+					score -= 2
+				}
+			}
+		}
+
+		p := URLPattern(item.URL)
+		if n := patterns[p]; n == 0 {
+			score += 2
+		} else {
+			score -= float64(min(n, 10)) * 0.5
+		}
+		patterns[p]++
+
+		score += float64(min(item.ParentNewJS, 10)) * 0.5
+		score -= float64(item.Depth) * 0.25
+		return score
+	}
+}
+
+// pageBudget counts pages against MaxPages, per seed when perSeed is set and
+// for the whole crawl otherwise. Pages are counted when a worker takes them,
+// not when they finish, so concurrent workers cannot overshoot the limit.
+// It is not safe for concurrent use; the engine guards it with its lock.
+type pageBudget struct {
+	max     int // 0 means unlimited
+	perSeed bool
+	used    map[string]int
+}
+
+func newPageBudget(max int, perSeed bool) *pageBudget {
+	if max < 0 {
+		max = 0
+	}
+	return &pageBudget{max: max, perSeed: perSeed, used: make(map[string]int)}
+}
+
+func (b *pageBudget) key(item FrontierItem) string {
+	if b.perSeed {
+		return item.Seed
+	}
+	return ""
+}
+
+// left reports whether item's seed (or the crawl) may take another page.
+func (b *pageBudget) left(item FrontierItem) bool {
+	return b.max == 0 || b.used[b.key(item)] < b.max
+}
+
+// take counts item as crawled.
+func (b *pageBudget) take(item FrontierItem) { b.used[b.key(item)]++ }
+
+// giveBack uncounts a taken item that was skipped or requeued.
+func (b *pageBudget) giveBack(item FrontierItem) { b.used[b.key(item)]-- }
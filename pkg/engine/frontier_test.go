@@ -0,0 +1,45 @@
+package engine
+
+import "testing"
+
+func TestNewFrontier(t *testing.T) {
+	byDepth := func(item FrontierItem) float64 { return float64(item.Depth) }
+	cases := []struct {
+		strategy string
+		score    ScoreFunc
+		want     []string // pop order after pushing a, b, c
+		wantErr  bool
+	}{
+		{strategy: "", want: []string{"a", "b", "c"}},
+		{strategy: "bfs", want: []string{"a", "b", "c"}},
+		{strategy: "BFS", want: []string{"a", "b", "c"}},
+		{strategy: "dfs", want: []string{"c", "b", "a"}},
+		{strategy: "score", score: byDepth, want: []string{"b", "c", "a"}},
+		{strategy: "", score: byDepth, want: []string{"b", "c", "a"}},
+		{strategy: "best-first", wantErr: true},
+	}
+	for _, c := range cases {
+		f, err := NewFrontier(c.strategy, c.score)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("NewFrontier(%q): expected error", c.strategy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("NewFrontier(%q): %v", c.strategy, err)
+		}
+		f.Push(FrontierItem{URL: "a", Depth: 0})
+		f.Push(FrontierItem{URL: "b", Depth: 2})
+		f.Push(FrontierItem{URL: "c", Depth: 2})
+		for i, want := range c.want {
+			item, ok := f.Pop()
+			if !ok || item.URL != want {
+				t.Errorf("NewFrontier(%q) pop %d = %q, want %q", c.strategy, i, item.URL, want)
+			}
+		}
+		if f.Len() != 0 {
+			t.Errorf("NewFrontier(%q): %d items left", c.strategy, f.Len())
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package engine
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+
+	"github.com/cyinnove/jscout/pkg/har"
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+// harRecorder turns the network events of one page load into HAR entries.
+// Requests blocked by jscout itself are left out since they were never sent.
+type harRecorder struct {
+	mu      sync.Mutex
+	pageID  string
+	started time.Time
+	bodies  bool // capture script and document bodies
+	pending map[network.RequestID]*harPending
+	entries []har.Entry
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// harPending is an entry waiting for its response to finish loading.
+type harPending struct {
+	entry  har.Entry
+	start  *cdp.MonotonicTime
+	timing *network.ResourceTiming
+	rtype  network.ResourceType
+}
+
+// harPageID names a page in the HAR log. The same URL is visited once per
+// device and locale profile, so those are part of the id.
+func harPageID(pageURL string, dev DeviceProfile, loc LocaleProfile) string {
+	var tags []string
+	if dev.Name != "" {
+		tags = append(tags, "profile="+dev.Name)
+	}
+	if loc.Name != "" {
+		tags = append(tags, "locale="+loc.Name)
+	}
+	if len(tags) == 0 {
+		return pageURL
+	}
+	return pageURL + " [" + strings.Join(tags, " ") + "]"
+}
+
+func newHARRecorder(pageID string, bodies bool) *harRecorder {
+	return &harRecorder{
+		pageID:  pageID,
+		started: time.Now(),
+		bodies:  bodies,
+		pending: make(map[network.RequestID]*harPending),
+	}
+}
+
+// handle is a chromedp.ListenTarget callback.
+func (h *harRecorder) handle(ctx context.Context, ev interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		if e.Request == nil {
+			return
+		}
+		// A redirect reuses the request id: close the previous hop first
+		if p, ok := h.pending[e.RequestID]; ok && e.RedirectResponse != nil {
+			p.setResponse(e.RedirectResponse)
+			p.entry.Response.RedirectURL = e.Request.URL
+			h.complete(p, e.Timestamp)
+		}
+		h.pending[e.RequestID] = h.newPending(e)
+	case *network.EventResponseReceived:
+		if p, ok := h.pending[e.RequestID]; ok && e.Response != nil {
+			p.setResponse(e.Response)
+		}
+	case *network.EventDataReceived:
+		if p, ok := h.pending[e.RequestID]; ok {
+			p.entry.Response.Content.Size += e.DataLength
+		}
+	case *network.EventLoadingFinished:
+		p, ok := h.pending[e.RequestID]
+		if !ok {
+			return
+		}
+		delete(h.pending, e.RequestID)
+		p.entry.Response.BodySize = int64(e.EncodedDataLength)
+		if h.bodies && (p.rtype == network.ResourceTypeScript || p.rtype == network.ResourceTypeDocument) {
+			// Fetching the body is a CDP round trip; never block the event loop
+			h.wg.Add(1)
+			go func(id network.RequestID, finished *cdp.MonotonicTime) {
+				defer h.wg.Done()
+				body, err := responseBody(ctx, id)
+				h.mu.Lock()
+				defer h.mu.Unlock()
+				if err == nil {
+					setHARBody(&p.entry.Response.Content, body)
+				}
+				h.complete(p, finished)
+			}(e.RequestID, e.Timestamp)
+			return
+		}
+		h.complete(p, e.Timestamp)
+	case *network.EventLoadingFailed:
+		p, ok := h.pending[e.RequestID]
+		if !ok {
+			return
+		}
+		delete(h.pending, e.RequestID)
+		if e.BlockedReason != "" {
+			return
+		}
+		p.entry.Error = e.ErrorText
+		h.complete(p, e.Timestamp)
+	}
+}
+
+func (h *harRecorder) newPending(e *network.EventRequestWillBeSent) *harPending {
+	req := e.Request
+	reqURL := req.URL + req.URLFragment
+	headers := har.Headers(req.Headers)
+	p := &harPending{start: e.Timestamp, rtype: e.Type}
+	p.entry = har.Entry{
+		PageRef:         h.pageID,
+		StartedDateTime: har.Time(time.Now()),
+		Request: har.Request{
+			Method:      req.Method,
+			URL:         reqURL,
+			Cookies:     har.RequestCookies(headers),
+			Headers:     headers,
+			QueryString: harQuery(reqURL),
+			HeadersSize: -1,
+		},
+		Response: har.Response{
+			Cookies:     []har.Cookie{},
+			Headers:     []har.NameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		ResourceType: strings.ToLower(string(e.Type)),
+	}
+	if e.WallTime != nil {
+		p.entry.StartedDateTime = har.Time(e.WallTime.Time())
+	}
+	if req.HasPostData {
+		var sb strings.Builder
+		for _, d := range req.PostDataEntries {
+			if b, err := base64.StdEncoding.DecodeString(d.Bytes); err == nil {
+				sb.Write(b)
+			}
+		}
+		mime := ""
+		for _, nv := range headers {
+			if strings.EqualFold(nv.Name, "content-type") {
+				mime = nv.Value
+			}
+		}
+		p.entry.Request.PostData = &har.PostData{MimeType: mime, Text: sb.String()}
+		p.entry.Request.BodySize = int64(sb.Len())
+	}
+	return p
+}
+
+// setResponse fills the response side, preferring the request headers as
+// actually sent (cookies included) when Chrome reports them.
+func (p *harPending) setResponse(resp *network.Response) {
+	headers := har.Headers(resp.Headers)
+	p.entry.Response.Status = resp.Status
+	p.entry.Response.StatusText = resp.StatusText
+	p.entry.Response.HTTPVersion = harHTTPVersion(resp.Protocol)
+	p.entry.Response.Headers = headers
+	p.entry.Response.Cookies = har.ResponseCookies(headers)
+	p.entry.Response.Content.MimeType = resp.MimeType
+	p.entry.Request.HTTPVersion = p.entry.Response.HTTPVersion
+	if len(resp.RequestHeaders) > 0 {
+		p.entry.Request.Headers = har.Headers(resp.RequestHeaders)
+		p.entry.Request.Cookies = har.RequestCookies(p.entry.Request.Headers)
+	}
+	p.entry.ServerIPAddress = strings.Trim(resp.RemoteIPAddress, "[]")
+	p.timing = resp.Timing
+}
+
+// complete computes timings and appends the entry; callers hold h.mu.
+func (h *harRecorder) complete(p *harPending, finished *cdp.MonotonicTime) {
+	p.entry.Timings, p.entry.Time = harTimings(p.timing, p.start, finished)
+	h.entries = append(h.entries, p.entry)
+}
+
+// finish waits for body fetches, records requests still in flight as
+// unfinished and returns the page log. Later events are ignored.
+func (h *harRecorder) finish(page *model.PageRecord) *har.Log {
+	h.mu.Lock()
+	h.closed = true // no new body fetches start after this
+	h.mu.Unlock()
+	h.wg.Wait()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, p := range h.pending {
+		p.entry.Error = "unfinished when the page was closed"
+		h.complete(p, nil)
+		delete(h.pending, id)
+	}
+	log := har.NewLog()
+	log.Pages = append(log.Pages, har.Page{
+		StartedDateTime: har.Time(h.started),
+		ID:              h.pageID,
+		Title:           page.Title,
+		PageTimings:     har.PageTimings{OnContentLoad: -1, OnLoad: float64(page.LoadMS)},
+	})
+	log.Entries = append(log.Entries, h.entries...)
+	return log
+}
+
+func setHARBody(c *har.Content, body []byte) {
+	if utf8.Valid(body) {
+		c.Text = string(body)
+		return
+	}
+	c.Text = base64.StdEncoding.EncodeToString(body)
+	c.Encoding = "base64"
+}
+
+func harQuery(raw string) []har.NameValue {
+	out := []har.NameValue{}
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return out
+	}
+	// Keep the order and duplicates of the query as sent
+	for _, kv := range strings.Split(u.RawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		if uk, err := url.QueryUnescape(k); err == nil {
+			k = uk
+		}
+		if uv, err := url.QueryUnescape(v); err == nil {
+			v = uv
+		}
+		out = append(out, har.NameValue{Name: k, Value: v})
+	}
+	return out
+}
+
+func harHTTPVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "h2":
+		return "HTTP/2"
+	case "h3", "h3-29", "quic":
+		return "HTTP/3"
+	case "":
+		return ""
+	default:
+		return strings.ToUpper(protocol)
+	}
+}
+
+// harTimings splits a request into HAR phases from Chrome's resource
+// timing, whose offsets are milliseconds relative to timing.RequestTime.
+func harTimings(t *network.ResourceTiming, start, finished *cdp.MonotonicTime) (har.Timings, float64) {
+	ms := func(m *cdp.MonotonicTime) float64 { return float64(m.Time().UnixNano()) / 1e6 }
+	total := 0.0
+	if start != nil && finished != nil {
+		total = max(ms(finished)-ms(start), 0)
+	}
+	if t == nil || start == nil {
+		return har.Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: total}, total
+	}
+	phase := func(from, to float64) float64 {
+		if from < 0 || to < 0 {
+			return -1
+		}
+		return to - from
+	}
+	first := t.SendStart
+	for _, v := range []float64{t.ConnectStart, t.DNSStart} {
+		if v >= 0 {
+			first = v
+		}
+	}
+	base := t.RequestTime * 1000
+	tm := har.Timings{
+		Blocked: max(base-ms(start)+first, 0),
+		DNS:     phase(t.DNSStart, t.DNSEnd),
+		Connect: phase(t.ConnectStart, t.ConnectEnd),
+		SSL:     phase(t.SslStart, t.SslEnd),
+		Send:    max(t.SendEnd-t.SendStart, 0),
+		Wait:    max(t.ReceiveHeadersEnd-t.SendEnd, 0),
+	}
+	if finished != nil {
+		tm.Receive = max(ms(finished)-(base+t.ReceiveHeadersEnd), 0)
+	}
+	// connect already includes ssl, as the HAR spec requires
+	return tm, tm.Blocked + max(tm.DNS, 0) + max(tm.Connect, 0) + tm.Send + tm.Wait + tm.Receive
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/chromedp/cdproto/debugger"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+// asyncStackDepth is how many async hops (promise, setTimeout, dynamic
+// import) Chrome keeps when attributing a request to a script.
+const asyncStackDepth = 16
+
+// enableInitiatorStacks turns on the Debugger domain, which Chrome requires
+// before it attaches JavaScript stack traces to request initiators. With the
+// debugger attached a `debugger;` statement or an exception breakpoint would
+// freeze the page until the timeout, so all pauses are skipped.
+func enableInitiatorStacks(ctx context.Context) error {
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		if _, err := debugger.Enable().Do(ctx); err != nil {
+			return err
+		}
+		if err := debugger.SetSkipAllPauses(true).Do(ctx); err != nil {
+			return err
+		}
+		return debugger.SetAsyncCallStackDepth(asyncStackDepth).Do(ctx)
+	}))
+}
+
+// applyInitiator copies the initiator type, the script or document that
+// caused the request and its top stack frame onto rec. Line and column are
+// converted to 1-based positions.
+func applyInitiator(rec *model.JSRecord, in *network.Initiator) {
+	if in == nil {
+		return
+	}
+	rec.InitiatorType = string(in.Type)
+	rec.InitiatorURL = in.URL
+	if in.URL != "" {
+		rec.InitiatorLine = int64(in.LineNumber) + 1
+		rec.InitiatorColumn = int64(in.ColumnNumber) + 1
+	}
+	// Prefer the innermost stack frame that belongs to a script with a URL,
+	// following async parents when the synchronous stack is anonymous.
+	for st := in.Stack; st != nil; st = st.Parent {
+		for _, f := range st.CallFrames {
+			if f == nil || f.URL == "" {
+				continue
+			}
+			rec.InitiatorURL = f.URL
+			rec.InitiatorFunction = f.FunctionName
+			rec.InitiatorLine = f.LineNumber + 1
+			rec.InitiatorColumn = f.ColumnNumber + 1
+			return
+		}
+	}
+}
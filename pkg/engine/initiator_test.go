@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+func TestApplyInitiator(t *testing.T) {
+	cases := []struct {
+		name         string
+		in           *network.Initiator
+		wantType     string
+		wantURL      string
+		wantFunction string
+		wantLine     int64
+		wantColumn   int64
+	}{
+		{name: "nil", in: nil},
+		{
+			name:     "parser",
+			in:       &network.Initiator{Type: network.InitiatorTypeParser, URL: "https://a.com/", LineNumber: 9, ColumnNumber: 4},
+			wantType: "parser", wantURL: "https://a.com/", wantLine: 10, wantColumn: 5,
+		},
+		{
+			name: "script frame",
+			in: &network.Initiator{Type: network.InitiatorTypeScript, Stack: &runtime.StackTrace{CallFrames: []*runtime.CallFrame{
+				{URL: "https://a.com/main.js", FunctionName: "load", LineNumber: 0, ColumnNumber: 99},
+			}}},
+			wantType: "script", wantURL: "https://a.com/main.js", wantFunction: "load", wantLine: 1, wantColumn: 100,
+		},
+		{
+			name: "async parent",
+			in: &network.Initiator{Type: network.InitiatorTypeScript, Stack: &runtime.StackTrace{
+				CallFrames: []*runtime.CallFrame{{FunctionName: "anonymous"}},
+				Parent: &runtime.StackTrace{CallFrames: []*runtime.CallFrame{
+					{URL: "https://a.com/router.js", FunctionName: "route", LineNumber: 41, ColumnNumber: 7},
+				}},
+			}},
+			wantType: "script", wantURL: "https://a.com/router.js", wantFunction: "route", wantLine: 42, wantColumn: 8,
+		},
+	}
+	for _, c := range cases {
+		rec := &model.JSRecord{}
+		applyInitiator(rec, c.in)
+		if rec.InitiatorType != c.wantType || rec.InitiatorURL != c.wantURL || rec.InitiatorFunction != c.wantFunction ||
+			rec.InitiatorLine != c.wantLine || rec.InitiatorColumn != c.wantColumn {
+			t.Errorf("%s: got %s %s %s %d:%d, want %s %s %s %d:%d", c.name,
+				rec.InitiatorType, rec.InitiatorURL, rec.InitiatorFunction, rec.InitiatorLine, rec.InitiatorColumn,
+				c.wantType, c.wantURL, c.wantFunction, c.wantLine, c.wantColumn)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezones are validated where the system has no zoneinfo too
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// LocaleProfile is the region a crawl pretends to browse from: its
+// language (Accept-Language, navigator.language and Intl), timezone and
+// position. Sites pick consent flows, payment providers and gated features
+// from these signals. Empty fields are left as the browser has them.
+type LocaleProfile struct {
+	Name     string // tag put on records and pages
+	Locale   string // BCP 47 language tag, e.g. de-DE
+	Timezone string // IANA timezone, e.g. Europe/Berlin
+	Geo      *Geolocation
+}
+
+// Geolocation is a position reported to the Geolocation API.
+type Geolocation struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  float64 // meters
+}
+
+// localeProfiles are the built-in regions accepted by LookupLocale.
+var localeProfiles = map[string]LocaleProfile{
+	"us": {Name: "us", Locale: "en-US", Timezone: "America/New_York", Geo: &Geolocation{40.7128, -74.0060, 100}},
+	"uk": {Name: "uk", Locale: "en-GB", Timezone: "Europe/London", Geo: &Geolocation{51.5074, -0.1278, 100}},
+	"de": {Name: "de", Locale: "de-DE", Timezone: "Europe/Berlin", Geo: &Geolocation{52.5200, 13.4050, 100}},
+	"fr": {Name: "fr", Locale: "fr-FR", Timezone: "Europe/Paris", Geo: &Geolocation{48.8566, 2.3522, 100}},
+	"es": {Name: "es", Locale: "es-ES", Timezone: "Europe/Madrid", Geo: &Geolocation{40.4168, -3.7038, 100}},
+	"it": {Name: "it", Locale: "it-IT", Timezone: "Europe/Rome", Geo: &Geolocation{41.9028, 12.4964, 100}},
+	"nl": {Name: "nl", Locale: "nl-NL", Timezone: "Europe/Amsterdam", Geo: &Geolocation{52.3676, 4.9041, 100}},
+	"br": {Name: "br", Locale: "pt-BR", Timezone: "America/Sao_Paulo", Geo: &Geolocation{-23.5505, -46.6333, 100}},
+	"in": {Name: "in", Locale: "en-IN", Timezone: "Asia/Kolkata", Geo: &Geolocation{19.0760, 72.8777, 100}},
+	"jp": {Name: "jp", Locale: "ja-JP", Timezone: "Asia/Tokyo", Geo: &Geolocation{35.6762, 139.6503, 100}},
+	"au": {Name: "au", Locale: "en-AU", Timezone: "Australia/Sydney", Geo: &Geolocation{-33.8688, 151.2093, 100}},
+}
+
+// localeTag is the syntax of a BCP 47 language tag: a language followed by
+// script, region and variant subtags.
+var localeTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// LookupLocale returns the built-in region called name (case-insensitive);
+// "gb" is accepted for "uk".
+func LookupLocale(name string) (LocaleProfile, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "gb" {
+		name = "uk"
+	}
+	p, ok := localeProfiles[name]
+	return p, ok
+}
+
+// LocaleNames lists the built-in region names, sorted.
+func LocaleNames() []string {
+	names := make([]string, 0, len(localeProfiles))
+	for name := range localeProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseGeolocation parses "lat,lon" or "lat,lon,accuracy".
+func ParseGeolocation(s string) (*Geolocation, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("invalid geolocation %q (use lat,lon[,accuracy])", s)
+	}
+	vals := make([]float64, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid geolocation %q (use lat,lon[,accuracy])", s)
+		}
+		vals[i] = v
+	}
+	g := &Geolocation{Latitude: vals[0], Longitude: vals[1], Accuracy: 100}
+	if len(vals) == 3 {
+		g.Accuracy = vals[2]
+	}
+	if !g.inRange() {
+		return nil, fmt.Errorf("geolocation %q out of range", s)
+	}
+	return g, nil
+}
+
+func (g *Geolocation) inRange() bool {
+	return g.Latitude >= -90 && g.Latitude <= 90 && g.Longitude >= -180 && g.Longitude <= 180 && g.Accuracy >= 0
+}
+
+// Validate checks the locale tag syntax, that the timezone is a known IANA
+// name and that the position is in range, so a typo fails the crawl up
+// front instead of leaving the browser's own settings under the profile's
+// name.
+func (l LocaleProfile) Validate() error {
+	if l.Locale != "" && !localeTag.MatchString(l.Locale) {
+		return fmt.Errorf("invalid locale %q (use a language tag such as de-DE)", l.Locale)
+	}
+	if l.Timezone != "" {
+		if _, err := time.LoadLocation(l.Timezone); err != nil || l.Timezone == "Local" {
+			return fmt.Errorf("unknown timezone %q (use an IANA name such as Europe/Berlin)", l.Timezone)
+		}
+	}
+	if l.Geo != nil && !l.Geo.inRange() {
+		return fmt.Errorf("geolocation %v,%v out of range", l.Geo.Latitude, l.Geo.Longitude)
+	}
+	return nil
+}
+
+// acceptLanguage is the Accept-Language value for the locale, e.g.
+// "de-DE,de;q=0.9" (or "" for no locale).
+func (l LocaleProfile) acceptLanguage() string {
+	if l.Locale == "" {
+		return ""
+	}
+	lang, _, found := strings.Cut(l.Locale, "-")
+	if !found {
+		return l.Locale
+	}
+	return l.Locale + "," + lang + ";q=0.9"
+}
+
+// languages is the navigator.languages list for the locale, e.g.
+// ["de-DE", "de"]; without a locale it is that of a US English browser.
+func (l LocaleProfile) languages() []string {
+	if l.Locale == "" {
+		return []string{"en-US", "en"}
+	}
+	lang, _, found := strings.Cut(l.Locale, "-")
+	if !found {
+		return []string{l.Locale}
+	}
+	return []string{l.Locale, lang}
+}
+
+// emulate applies the timezone, Intl locale and position to the tab in
+// ctx. Accept-Language and navigator.language go with the User-Agent
+// override. A reused tab already carries the overrides of its crawl, which
+// Chrome refuses to set twice; only that error is ignored.
+func (l LocaleProfile) emulate(ctx context.Context) error {
+	if l.Locale != "" {
+		if err := overrideInEffect(chromedp.Run(ctx, emulation.SetLocaleOverride().WithLocale(l.Locale))); err != nil {
+			return fmt.Errorf("locale override %s: %w", l.Locale, err)
+		}
+	}
+	if l.Timezone != "" {
+		if err := overrideInEffect(chromedp.Run(ctx, emulation.SetTimezoneOverride(l.Timezone))); err != nil {
+			return fmt.Errorf("timezone override %s: %w", l.Timezone, err)
+		}
+	}
+	if l.Geo == nil {
+		return nil
+	}
+	bcID := chromedp.FromContext(ctx).BrowserContextID
+	if err := browserExec(ctx, func(ctx context.Context) error {
+		grant := browser.GrantPermissions([]browser.PermissionType{browser.PermissionTypeGeolocation})
+		if bcID != "" {
+			grant = grant.WithBrowserContextID(bcID)
+		}
+		return grant.Do(ctx)
+	}); err != nil {
+		return fmt.Errorf("grant geolocation: %w", err)
+	}
+	return chromedp.Run(ctx, emulation.SetGeolocationOverride().
+		WithLatitude(l.Geo.Latitude).
+		WithLongitude(l.Geo.Longitude).
+		WithAccuracy(l.Geo.Accuracy))
+}
+
+// overrideInEffect drops the error Chrome returns for an override the tab
+// already has ("... override is already in effect").
+func overrideInEffect(err error) error {
+	if err != nil && strings.Contains(err.Error(), "already in effect") {
+		return nil
+	}
+	return err
+}
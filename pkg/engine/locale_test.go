@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLocaleLanguages(t *testing.T) {
+	cases := []struct {
+		locale     string
+		languages  []string
+		acceptLang string
+	}{
+		{"", []string{"en-US", "en"}, ""},
+		{"de-DE", []string{"de-DE", "de"}, "de-DE,de;q=0.9"},
+		{"pt-BR", []string{"pt-BR", "pt"}, "pt-BR,pt;q=0.9"},
+		{"fr", []string{"fr"}, "fr"},
+	}
+	for _, c := range cases {
+		l := LocaleProfile{Locale: c.locale}
+		if got := l.languages(); !reflect.DeepEqual(got, c.languages) {
+			t.Errorf("languages(%q) = %v, want %v", c.locale, got, c.languages)
+		}
+		if got := l.acceptLanguage(); got != c.acceptLang {
+			t.Errorf("acceptLanguage(%q) = %q, want %q", c.locale, got, c.acceptLang)
+		}
+	}
+}
+
+func TestParseGeolocation(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Geolocation
+		wantErr bool
+	}{
+		{in: "52.52,13.405", want: Geolocation{52.52, 13.405, 100}},
+		{in: " -33.8688 , 151.2093 , 25 ", want: Geolocation{-33.8688, 151.2093, 25}},
+		{in: "90,-180,0", want: Geolocation{90, -180, 0}},
+		{in: "52.52", wantErr: true},
+		{in: "1,2,3,4", wantErr: true},
+		{in: "north,13", wantErr: true},
+		{in: "91,0", wantErr: true},
+		{in: "0,181", wantErr: true},
+		{in: "0,0,-1", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, c := range cases {
+		g, err := ParseGeolocation(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ParseGeolocation(%q): expected error, got %+v", c.in, g)
+			}
+			continue
+		}
+		if err != nil || *g != c.want {
+			t.Errorf("ParseGeolocation(%q) = %+v, %v; want %+v", c.in, g, err, c.want)
+		}
+	}
+}
+
+func TestLocaleProfileValidate(t *testing.T) {
+	cases := []struct {
+		profile LocaleProfile
+		wantErr bool
+	}{
+		{profile: LocaleProfile{}},
+		{profile: LocaleProfile{Locale: "de-DE", Timezone: "Europe/Berlin", Geo: &Geolocation{52.52, 13.405, 100}}},
+		{profile: LocaleProfile{Locale: "zh-Hant-TW", Timezone: "UTC"}},
+		{profile: LocaleProfile{Locale: "es-419"}},
+		{profile: LocaleProfile{Locale: "de_DE"}, wantErr: true},
+		{profile: LocaleProfile{Locale: "german"}, wantErr: true},
+		{profile: LocaleProfile{Timezone: "Europe/Berlni"}, wantErr: true},
+		{profile: LocaleProfile{Timezone: "Local"}, wantErr: true},
+		{profile: LocaleProfile{Geo: &Geolocation{Latitude: 100}}, wantErr: true},
+	}
+	for _, c := range cases {
+		if err := c.profile.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("Validate(%+v) = %v, wantErr %v", c.profile, err, c.wantErr)
+		}
+	}
+	for name := range localeProfiles {
+		p, _ := LookupLocale(name)
+		if err := p.Validate(); err != nil {
+			t.Errorf("built-in region %s: %v", name, err)
+		}
+	}
+}
+
+func TestOverrideInEffect(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool // error kept
+	}{
+		{nil, false},
+		{errors.New("Timezone override is already in effect"), false},
+		{errors.New("Another locale override is already in effect"), false},
+		{errors.New("Invalid timezone ID"), true},
+	}
+	for _, c := range cases {
+		if got := overrideInEffect(c.err); (got != nil) != c.want {
+			t.Errorf("overrideInEffect(%v) = %v, want kept=%v", c.err, got, c.want)
+		}
+	}
+}
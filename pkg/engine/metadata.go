@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+// interestingHeaders are response headers copied onto JS records: origin
+// server and caching metadata plus the markers common CDNs add.
+var interestingHeaders = map[string]struct{}{
+	"server": {}, "last-modified": {}, "etag": {}, "cache-control": {}, "age": {}, "via": {},
+	"x-cache": {}, "x-cache-hits": {}, "cdn-cache-control": {}, "x-cdn": {},
+	"cf-cache-status": {}, "cf-ray": {}, // Cloudflare
+	"x-amz-cf-id": {}, "x-amz-cf-pop": {}, // CloudFront
+	"x-served-by": {}, "x-fastly-request-id": {}, // Fastly
+	"akamai-cache-status": {}, "x-akamai-transformed": {}, // Akamai
+	"x-azure-ref": {}, "x-vercel-cache": {}, "x-nf-request-id": {}, // Azure, Vercel, Netlify
+	"sourcemap": {}, "x-sourcemap": {}, // source map location
+}
+
+// pickHeaders returns the interesting subset of headers with lower-cased names.
+func pickHeaders(h network.Headers) map[string]string {
+	out := make(map[string]string)
+	for k, v := range h {
+		lk := strings.ToLower(k)
+		if _, ok := interestingHeaders[lk]; ok {
+			out[lk] = fmt.Sprint(v)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// scriptAttrs describes how a script is referenced from the DOM.
+type scriptAttrs struct {
+	URL         string `json:"url"`
+	Module      bool   `json:"module"`
+	Async       bool   `json:"async"`
+	Defer       bool   `json:"defer"`
+	Integrity   string `json:"integrity"`
+	CrossOrigin string `json:"crossorigin"`
+}
+
+// scriptAttrsScript lists script[src] and script preload links with their
+// loading attributes. URLs are returned as written (minus fragment) and
+// normalized on the Go side to match JS record keys.
+const scriptAttrsScript = `
+(function() {
+	const out = [];
+	const clean = (u) => u.split('#')[0];
+	document.querySelectorAll('script[src]').forEach(s => {
+		try {
+			out.push({
+				url: clean(new URL(s.getAttribute('src'), location.href).href),
+				module: (s.type || '').toLowerCase() === 'module',
+				async: s.async, defer: s.defer,
+				integrity: s.integrity || '',
+				crossorigin: s.getAttribute('crossorigin') === null ? '' : (s.getAttribute('crossorigin') || 'anonymous'),
+			});
+		} catch(e) {}
+	});
+	document.querySelectorAll('link[rel="modulepreload"], link[rel="preload"][as="script"]').forEach(l => {
+		try {
+			out.push({
+				url: clean(new URL(l.getAttribute('href'), location.href).href),
+				module: l.rel === 'modulepreload',
+				async: false, defer: false,
+				integrity: l.integrity || '',
+				crossorigin: l.getAttribute('crossorigin') === null ? '' : (l.getAttribute('crossorigin') || 'anonymous'),
+			});
+		} catch(e) {}
+	});
+	return out;
+})()
+`
+
+// applyScriptAttrs copies DOM loading attributes onto records by normalized
+// URL. A script tag wins over a preload hint for the same URL.
+func applyScriptAttrs(records []*model.JSRecord, attrs []scriptAttrs, normalize func(string) string) {
+	byURL := make(map[string]scriptAttrs, len(attrs))
+	for _, a := range attrs {
+		key := normalize(a.URL)
+		if _, ok := byURL[key]; !ok {
+			byURL[key] = a
+		}
+	}
+	for _, r := range records {
+		a, ok := byURL[r.JSURL]
+		if !ok {
+			continue
+		}
+		r.Module = r.Module || a.Module
+		r.Async = a.Async
+		r.Defer = a.Defer
+		r.Integrity = a.Integrity
+		r.CrossOrigin = a.CrossOrigin
+	}
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/cyinnove/jscout/pkg/har"
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+// pageResult is everything collectJSOnPage learns about one page.
+type pageResult struct {
+	JS    []*model.JSRecord
+	Links []string
+	Page  *model.PageRecord
+	HAR   *har.Log // nil unless Options.HAR
+}
+
+// failedPage is the result of a page that could not even be opened.
+func failedPage(pageURL string, err error) *pageResult {
+	page := &model.PageRecord{URL: pageURL, Error: err.Error()}
+	page.ErrorKind, page.ErrorCode = classifyError(err)
+	return &pageResult{Page: page}
+}
+
+var netErrCode = regexp.MustCompile(`net::ERR_[A-Z0-9_]+`)
+
+// Error kinds reported in model.PageRecord.ErrorKind.
+const (
+	ErrorKindTimeout = "timeout"
+	ErrorKindDNS     = "dns"
+	ErrorKindTLS     = "tls"
+	ErrorKindNetwork = "network"
+	ErrorKindBrowser = "browser"
+)
+
+// classifyError maps a page load error to a coarse kind (timeout, dns, tls,
+// network, browser) plus the raw Chrome net::ERR_* code when present.
+func classifyError(err error) (kind, code string) {
+	if err == nil {
+		return "", ""
+	}
+	code = netErrCode.FindString(err.Error())
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || code == "net::ERR_TIMED_OUT" || code == "net::ERR_CONNECTION_TIMED_OUT":
+		return ErrorKindTimeout, code
+	case code == "net::ERR_NAME_NOT_RESOLVED" || code == "net::ERR_NAME_RESOLUTION_FAILED":
+		return ErrorKindDNS, code
+	case strings.HasPrefix(code, "net::ERR_CERT_") || strings.HasPrefix(code, "net::ERR_SSL_"):
+		return ErrorKindTLS, code
+	case code != "":
+		return ErrorKindNetwork, code
+	}
+	return ErrorKindBrowser, ""
+}
@@ -0,0 +1,305 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// maxRestartFailures is how many launches in a row may fail before a
+	// pool slot is given up.
+	maxRestartFailures = 3
+	// maxPageRetries is how often a page is retried after its browser died
+	// under it.
+	maxPageRetries = 2
+	// rssCheckEvery is how often (in pages) a browser's memory is measured.
+	rssCheckEvery = 10
+	// healthCheckTimeout bounds the ping sent after a browser-level page error.
+	healthCheckTimeout = 5 * time.Second
+)
+
+var errPoolDead = errors.New("no browser could be (re)started")
+
+// browserPool runs Options.Browsers browsers (or connections to a remote
+// one) and spreads tabs across them. A browser that crashes or disconnects
+// is restarted; one that has served RecycleAfterPages pages or grown past
+// RecycleRSSMB is drained and restarted between pages.
+type browserPool struct {
+	rootCtx  context.Context
+	opt      Options
+	execOpts []chromedp.ExecAllocatorOption
+	cookies  []*network.CookieParam
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	browsers []*pooledBrowser
+	restarts int
+	lastErr  error
+	closed   bool
+}
+
+type pooledBrowser struct {
+	ctx        context.Context // browser context; tabs are created from it
+	cancel     func()
+	pages      int  // pages served since the last (re)start
+	active     int  // tabs open right now
+	draining   bool // restart once active drops to zero
+	restarting bool
+	failures   int // launches failed in a row
+	gen        int // bumped on every restart; tabs from older generations are gone
+
+	ctxMu    sync.Mutex
+	contexts map[string]cdp.BrowserContextID // isolated browser contexts by isolation key
+}
+
+func newBrowserPool(rootCtx context.Context, opt Options, execOpts []chromedp.ExecAllocatorOption) (*browserPool, error) {
+	n := opt.Browsers
+	if n <= 0 {
+		n = 1
+	}
+	p := &browserPool{rootCtx: rootCtx, opt: opt, execOpts: execOpts, cookies: cookieParams(opt.Cookies, opt.AllowedHosts)}
+	p.cond = sync.NewCond(&p.mu)
+	for i := 0; i < n; i++ {
+		ctx, cancel, err := p.launch()
+		if err != nil {
+			p.close()
+			return nil, err
+		}
+		p.browsers = append(p.browsers, &pooledBrowser{ctx: ctx, cancel: cancel, contexts: map[string]cdp.BrowserContextID{}})
+	}
+	return p, nil
+}
+
+// launch starts a browser, or connects to the remote one, and waits until it
+// answers so that failures surface here rather than on every page.
+func (p *browserPool) launch() (context.Context, func(), error) {
+	var alloc context.Context
+	var cancelAlloc context.CancelFunc
+	if p.opt.RemoteURL != "" {
+		wsURL, err := ResolveDevToolsURL(p.rootCtx, p.opt.RemoteURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("remote browser: %w", err)
+		}
+		alloc, cancelAlloc = chromedp.NewRemoteAllocator(p.rootCtx, wsURL, chromedp.NoModifyURL)
+	} else {
+		alloc, cancelAlloc = chromedp.NewExecAllocator(p.rootCtx, p.execOpts...)
+	}
+	ctx, cancelCtx := chromedp.NewContext(alloc)
+	if err := chromedp.Run(ctx); err != nil {
+		cancelCtx()
+		cancelAlloc()
+		if p.opt.RemoteURL != "" {
+			return nil, nil, fmt.Errorf("remote browser: %w", err)
+		}
+		return nil, nil, fmt.Errorf("launch browser: %w", err)
+	}
+	if err := setDefaultCookies(ctx, p.cookies); err != nil {
+		cancelCtx()
+		cancelAlloc()
+		return nil, nil, fmt.Errorf("set cookies: %w", err)
+	}
+	return ctx, func() { cancelCtx(); cancelAlloc() }, nil
+}
+
+// acquire reserves a tab slot on prefer (the browser holding the caller's
+// tab) when it is healthy, else on the least busy healthy browser,
+// restarting crashed and idle draining ones first. It fails only when every
+// slot has been given up.
+func (p *browserPool) acquire(prefer *pooledBrowser) (*pooledBrowser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+outer:
+	for {
+		if p.closed {
+			return nil, errPoolDead
+		}
+		for _, b := range p.browsers {
+			if b.ctx != nil && b.ctx.Err() != nil {
+				b.draining = true // crashed or disconnected
+			}
+			if b.restarting {
+				continue
+			}
+			if (b.draining && b.active == 0) || (b.ctx == nil && b.failures < maxRestartFailures) {
+				p.restart(b) // unlocks while launching, so look again
+				continue outer
+			}
+		}
+		if prefer != nil && prefer.ctx != nil && prefer.ctx.Err() == nil && !prefer.draining && !prefer.restarting {
+			prefer.active++
+			return prefer, nil
+		}
+		var best *pooledBrowser
+		pending := false
+		for _, b := range p.browsers {
+			switch {
+			case b.restarting || (b.ctx != nil && b.draining):
+				pending = true
+			case b.ctx != nil && (best == nil || b.active < best.active):
+				best = b
+			}
+		}
+		if best != nil {
+			best.active++
+			return best, nil
+		}
+		if !pending {
+			if p.lastErr != nil {
+				return nil, fmt.Errorf("%w: %v", errPoolDead, p.lastErr)
+			}
+			return nil, errPoolDead
+		}
+		p.cond.Wait()
+	}
+}
+
+// release returns a tab slot after a page and reports whether the browser
+// died under it, in which case the page should be retried.
+func (p *browserPool) release(b *pooledBrowser, pageErr error) bool {
+	dead := b.ctx.Err() != nil
+	if !dead && pageErr != nil {
+		if kind, _ := classifyError(pageErr); kind == ErrorKindBrowser {
+			dead = !p.healthy(b)
+		}
+	}
+	var rss int64
+	if !dead && p.opt.RecycleRSSMB > 0 && (b.pages+1)%rssCheckEvery == 0 {
+		if proc := chromedp.FromContext(b.ctx).Browser.Process(); proc != nil {
+			rss = processTreeRSS(proc.Pid)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b.active--
+	b.pages++
+	switch {
+	case dead:
+		b.draining = true
+	case p.opt.RecycleAfterPages > 0 && b.pages >= p.opt.RecycleAfterPages:
+		b.draining = true
+	case p.opt.RecycleRSSMB > 0 && rss > int64(p.opt.RecycleRSSMB)<<20:
+		b.draining = true
+	}
+	if b.draining && b.active == 0 && !b.restarting && !p.closed {
+		p.restart(b)
+	}
+	p.cond.Broadcast()
+	return dead
+}
+
+// browserContext returns the isolated browser context for key on b,
+// creating it on first use with the configured cookies set for hosts. An
+// empty key always creates a new context, which the caller disposes with
+// dropContext.
+func (p *browserPool) browserContext(b *pooledBrowser, key string, hosts []string) (cdp.BrowserContextID, error) {
+	b.ctxMu.Lock()
+	defer b.ctxMu.Unlock()
+	if id, ok := b.contexts[key]; ok && key != "" {
+		return id, nil
+	}
+	id, err := createBrowserContext(b.ctx, cookieParams(p.opt.Cookies, hosts))
+	if err != nil {
+		return "", err
+	}
+	if key != "" {
+		b.contexts[key] = id
+	}
+	return id, nil
+}
+
+// dropContext disposes a per-page browser context.
+func (p *browserPool) dropContext(b *pooledBrowser, id cdp.BrowserContextID) {
+	disposeBrowserContext(b.ctx, id)
+}
+
+// disposeContexts releases b's browser contexts, which matters for remote
+// browsers that outlive the crawl. It must not run concurrently with tabs
+// on b.
+func disposeContexts(bctx context.Context, b *pooledBrowser) {
+	b.ctxMu.Lock()
+	defer b.ctxMu.Unlock()
+	if bctx != nil && bctx.Err() == nil {
+		for _, id := range b.contexts {
+			disposeBrowserContext(bctx, id)
+		}
+	}
+	b.contexts = map[string]cdp.BrowserContextID{}
+}
+
+// healthy pings the browser; a browser that does not answer is treated as
+// crashed.
+func (p *browserPool) healthy(b *pooledBrowser) bool {
+	ctx, cancel := context.WithTimeout(b.ctx, healthCheckTimeout)
+	defer cancel()
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, _, _, _, err := browser.GetVersion().Do(ctx)
+		return err
+	})) == nil
+}
+
+// restart replaces b's browser. It is called with p.mu held and releases it
+// while the old browser is closed and the new one launched.
+func (p *browserPool) restart(b *pooledBrowser) {
+	oldCtx, old := b.ctx, b.cancel
+	b.ctx, b.cancel = nil, nil
+	b.restarting = true
+	p.mu.Unlock()
+	disposeContexts(oldCtx, b)
+	if old != nil {
+		old()
+	}
+	ctx, cancel, err := p.launch()
+	p.mu.Lock()
+	b.restarting, b.draining, b.pages = false, false, 0
+	if err != nil {
+		b.failures++
+		p.lastErr = err
+	} else if p.closed {
+		cancel()
+	} else {
+		b.ctx, b.cancel, b.failures = ctx, cancel, 0
+		b.gen++
+		p.restarts++
+	}
+	p.cond.Broadcast()
+}
+
+// restarted returns how many browsers were restarted after a crash or for
+// recycling.
+func (p *browserPool) restarted() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.restarts
+}
+
+func (p *browserPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	type open struct {
+		b      *pooledBrowser
+		ctx    context.Context
+		cancel func()
+	}
+	var opened []open
+	for _, b := range p.browsers {
+		if b.cancel != nil {
+			opened = append(opened, open{b, b.ctx, b.cancel})
+			b.ctx, b.cancel = nil, nil
+		}
+	}
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	for _, o := range opened {
+		disposeContexts(o.ctx, o.b)
+		o.cancel()
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ResolveDevToolsURL turns a remote debugging address into the browser's
+// websocket debugger URL. Accepted forms:
+//
+//   - ws://host:9222/devtools/browser/<id>, used as is
+//   - ws(s)://host:port/path?token=..., used as is (browserless and similar
+//     services accept the connection on their own paths)
+//   - http(s)://host:9222, ws://host:9222 or host:9222, discovered through
+//     /json/version
+//
+// A discovered URL keeps the host and port that were asked for, since
+// Chrome in a container or behind a proxy reports its own address.
+func ResolveDevToolsURL(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid remote debugging url %q", raw)
+	}
+	switch u.Scheme {
+	case "ws", "wss":
+		if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" {
+			return u.String(), nil
+		}
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("unsupported remote debugging url scheme %q (use ws, wss, http or https)", u.Scheme)
+	}
+	return discoverDevToolsURL(ctx, u)
+}
+
+// discoverDevToolsURL asks the /json/version endpoint of u for the
+// websocket debugger URL.
+func discoverDevToolsURL(ctx context.Context, u *url.URL) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	ver := *u
+	switch ver.Scheme {
+	case "ws":
+		ver.Scheme = "http"
+	case "wss":
+		ver.Scheme = "https"
+	}
+	ver.Path = "/json/version"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ver.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", ver.String(), resp.Status)
+	}
+	var info struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", fmt.Errorf("%s: %w", ver.String(), err)
+	}
+	if info.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("%s: no webSocketDebuggerUrl in response", ver.String())
+	}
+
+	ws, err := url.Parse(info.WebSocketDebuggerURL)
+	if err != nil {
+		return "", err
+	}
+	ws.Host = u.Host
+	if u.Scheme == "https" || u.Scheme == "wss" {
+		ws.Scheme = "wss"
+	}
+	if ws.RawQuery == "" {
+		ws.RawQuery = u.RawQuery
+	}
+	return ws.String(), nil
+}
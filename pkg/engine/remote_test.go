@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveDevToolsURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/version" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"Browser":"Chrome/126","webSocketDebuggerUrl":"ws://127.0.0.1:9222/devtools/browser/abc"}`)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	cases := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "ws://remote:9222/devtools/browser/xyz", want: "ws://remote:9222/devtools/browser/xyz"},
+		{in: "wss://chrome.example.com?token=t", want: "wss://chrome.example.com?token=t"},
+		{in: srv.URL, want: "ws://" + host + "/devtools/browser/abc"},
+		{in: host, want: "ws://" + host + "/devtools/browser/abc"},
+		{in: "ws://" + host, want: "ws://" + host + "/devtools/browser/abc"},
+		{in: "ftp://" + host, wantErr: true},
+		{in: "http://", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := ResolveDevToolsURL(context.Background(), c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ResolveDevToolsURL(%q): expected error, got %s", c.in, got)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("ResolveDevToolsURL(%q) = %q, %v; want %q", c.in, got, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// routeHookScript is injected before any page script runs. It wraps the
+// History API and listens for hash/pop navigations so client-side route
+// changes made by SPA routers are recorded in window.__jscoutRoutes.
+const routeHookScript = `
+(function() {
+	if (window.__jscoutRoutes) return;
+	const routes = new Set();
+	Object.defineProperty(window, '__jscoutRoutes', { value: routes, enumerable: false });
+	const record = (u) => {
+		try {
+			if (u === undefined || u === null || u === '') return;
+			routes.add(new URL(String(u), location.href).href);
+		} catch(e) {}
+	};
+	['pushState', 'replaceState'].forEach(name => {
+		const orig = history[name];
+		if (typeof orig !== 'function') return;
+		history[name] = function(state, title, u) {
+			record(u);
+			return orig.apply(this, arguments);
+		};
+	});
+	window.addEventListener('hashchange', () => record(location.href), true);
+	window.addEventListener('popstate', () => record(location.href), true);
+})();
+`
+
+// routeExtractScript collects routes recorded by routeHookScript and, when a
+// supported router is exposed at runtime, its route table. Dynamic segments
+// (":id", "*") cannot be visited without real values and are skipped.
+const routeExtractScript = `
+(function() {
+	const out = new Set();
+	const base = location.href;
+	const add = (u) => {
+		try {
+			if (!u || typeof u !== 'string') return;
+			out.add(new URL(u, base).href);
+		} catch(e) {}
+	};
+	const isStatic = (p) => typeof p === 'string' && !/[:*\[]/.test(p);
+
+	// History API / hash navigations observed so far
+	try { (window.__jscoutRoutes || []).forEach(add); } catch(e) {}
+
+	// Angular/Vue router-link attributes and inline onclick navigations
+	try {
+		document.querySelectorAll('[routerlink], [ng-reflect-router-link], [data-href], [data-url]').forEach(el => {
+			const v = el.getAttribute('routerlink') || el.getAttribute('ng-reflect-router-link') ||
+				el.getAttribute('data-href') || el.getAttribute('data-url');
+			if (isStatic(v)) add(v);
+		});
+		const navRe = /(?:location(?:\.href)?\s*=|location\.(?:assign|replace)\(|window\.open\(|navigate(?:ByUrl)?\(|push\()\s*['"\x60]([^'"\x60]+)['"\x60]/g;
+		document.querySelectorAll('[onclick]').forEach(el => {
+			const src = el.getAttribute('onclick') || '';
+			let m;
+			while ((m = navRe.exec(src)) !== null) {
+				if (isStatic(m[1])) add(m[1]);
+			}
+		});
+	} catch(e) {}
+
+	// Vue Router (Vue 3 app instance or Vue 2 root component)
+	try {
+		const roots = Array.from(document.querySelectorAll('*')).filter(el => el.__vue_app__ || el.__vue__).slice(0, 5);
+		roots.forEach(el => {
+			let router = null;
+			if (el.__vue_app__) router = el.__vue_app__.config.globalProperties.$router;
+			else if (el.__vue__) router = el.__vue__.$router || (el.__vue__.$root && el.__vue__.$root.$router);
+			if (!router) return;
+			const list = typeof router.getRoutes === 'function' ? router.getRoutes() : ((router.options && router.options.routes) || []);
+			const walk = (rs, prefix) => (rs || []).forEach(r => {
+				if (!r || typeof r.path !== 'string') return;
+				const p = r.path.startsWith('/') ? r.path : (prefix.replace(/\/$/, '') + '/' + r.path);
+				if (isStatic(p)) {
+					try { add(router.resolve(p).href); } catch(e) { add(p); }
+				}
+				walk(r.children, p);
+			});
+			walk(list, '/');
+		});
+	} catch(e) {}
+
+	// Angular Router (development builds expose window.ng)
+	try {
+		if (window.ng && typeof window.ng.getComponent === 'function') {
+			const hashMode = location.hash.startsWith('#/');
+			document.querySelectorAll('[ng-version]').forEach(el => {
+				const cmp = window.ng.getComponent(el);
+				if (!cmp) return;
+				Object.keys(cmp).forEach(k => {
+					const v = cmp[k];
+					if (!v || typeof v.navigateByUrl !== 'function' || !Array.isArray(v.config)) return;
+					const walk = (rs, prefix) => (rs || []).forEach(r => {
+						if (!r || typeof r.path !== 'string') return;
+						const p = (prefix + '/' + r.path).replace(/\/+/g, '/');
+						if (isStatic(p)) add(hashMode ? '#' + p : p);
+						walk(r.children, p);
+					});
+					walk(v.config, '');
+				});
+			});
+		}
+	} catch(e) {}
+
+	// React Router framework mode / Remix route manifests
+	try {
+		[window.__reactRouterManifest, window.__remixManifest].forEach(m => {
+			if (!m || !m.routes) return;
+			const byId = m.routes;
+			const full = (r) => {
+				const parts = [];
+				for (let cur = r; cur; cur = cur.parentId ? byId[cur.parentId] : null) {
+					if (cur.path) parts.unshift(cur.path);
+				}
+				return '/' + parts.join('/').replace(/^\/+/, '');
+			};
+			Object.values(byId).forEach(r => {
+				const p = full(r);
+				if (isStatic(p)) add(p);
+			});
+		});
+	} catch(e) {}
+
+	// Next.js build manifest
+	try {
+		const pages = (window.__BUILD_MANIFEST && window.__BUILD_MANIFEST.sortedPages) || [];
+		pages.forEach(p => {
+			if (p.startsWith('/_') || p.startsWith('/api/')) return;
+			if (isStatic(p)) add(p);
+		});
+	} catch(e) {}
+
+	return Array.from(out);
+})()
+`
+
+// installRouteHooks registers routeHookScript to run on every new document.
+func installRouteHooks(ctx context.Context) (page.ScriptIdentifier, error) {
+	var id page.ScriptIdentifier
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		id, err = page.AddScriptToEvaluateOnNewDocument(routeHookScript).Do(ctx)
+		return err
+	}))
+	return id, err
+}
+
+// collectRoutes returns client-side routes observed or exposed on the page.
+func collectRoutes(ctx context.Context) []string {
+	var routes []string
+	_ = chromedp.Run(ctx, chromedp.EvaluateAsDevTools(routeExtractScript, &routes))
+	return routes
+}
+
+// normalizePageURL drops plain in-page anchors ("#section") so they do not
+// count as separate pages, but keeps hash-based routes ("#/admin", "#!/x")
+// which SPAs render as distinct views.
+func normalizePageURL(u *url.URL) string {
+	c := *u
+	if c.Fragment != "" && !isHashRoute(c.Fragment) {
+		c.Fragment = ""
+		c.RawFragment = ""
+	}
+	return c.String()
+}
+
+func isHashRoute(fragment string) bool {
+	return strings.HasPrefix(fragment, "/") || strings.HasPrefix(fragment, "!/")
+}
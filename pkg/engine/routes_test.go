@@ -0,0 +1,28 @@
+package engine
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestNormalizePageURL(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"https://a.com/page", "https://a.com/page"},
+		{"https://a.com/page#section", "https://a.com/page"},
+		{"https://a.com/#top", "https://a.com/"},
+		{"https://a.com/#/admin", "https://a.com/#/admin"},
+		{"https://a.com/#!/users/1", "https://a.com/#!/users/1"},
+		{"https://a.com/p?q=1#frag", "https://a.com/p?q=1"},
+	}
+	for _, c := range cases {
+		u, err := url.Parse(c.in)
+		if err != nil {
+			t.Fatalf("parse %s: %v", c.in, err)
+		}
+		if got := normalizePageURL(u); got != c.want {
+			t.Errorf("normalizePageURL(%s) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
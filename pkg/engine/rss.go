@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// processTreeRSS returns the resident memory in bytes of pid and all of its
+// descendants (Chrome keeps most memory in renderer and GPU children). It
+// reads /proc and returns 0 where that is not available.
+func processTreeRSS(pid int) int64 {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil || len(stats) == 0 {
+		return 0
+	}
+	children := map[int][]int{}
+	for _, path := range stats {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// pid (comm) state ppid ...; comm may contain spaces and parentheses
+		end := bytes.LastIndexByte(b, ')')
+		if end < 0 {
+			continue
+		}
+		fields := bytes.Fields(b[end+1:])
+		if len(fields) < 2 {
+			continue
+		}
+		child, err1 := strconv.Atoi(filepath.Base(filepath.Dir(path)))
+		parent, err2 := strconv.Atoi(string(fields[1]))
+		if err1 == nil && err2 == nil {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	page := int64(os.Getpagesize())
+	var total int64
+	queue := []int{pid}
+	seen := map[int]bool{}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		queue = append(queue, children[p]...)
+		// statm: size resident shared ... (in pages)
+		b, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(p), "statm"))
+		if err != nil {
+			continue
+		}
+		if f := bytes.Fields(b); len(f) > 1 {
+			if n, err := strconv.ParseInt(string(f[1]), 10, 64); err == nil {
+				total += n * page
+			}
+		}
+	}
+	return total
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestScopeOf(t *testing.T) {
+	e := New(Options{
+		AllowedHosts: []string{"a.com", "b.com"},
+		SeedScopes: map[string][]string{
+			"https://a.com/": {"a.com"},
+			"https://b.com/": {"b.com", "cdn.b.com"},
+		},
+	})
+	cases := []struct {
+		seed string
+		want []string
+	}{
+		{"https://a.com/", []string{"a.com"}},
+		{"https://b.com/", []string{"b.com", "cdn.b.com"}},
+		{"https://c.com/", []string{"a.com", "b.com"}},
+		{"", []string{"a.com", "b.com"}},
+	}
+	for _, c := range cases {
+		if got := e.scopeOf(c.seed); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("scopeOf(%q) = %v, want %v", c.seed, got, c.want)
+		}
+	}
+}
+
+// TestPageBudget drains a queue the way crawl workers do: take under the
+// lock when popping, give back on requeue. Every third page fails once and
+// is requeued, and no seed may end up with more than max crawled pages.
+func TestPageBudget(t *testing.T) {
+	cases := []struct {
+		name    string
+		max     int
+		perSeed bool
+		workers int
+		want    map[string]int // crawled pages per seed; nil checks only total
+		total   int
+	}{
+		{"per seed, one worker", 3, true, 1, map[string]int{"a": 3, "b": 3}, 6},
+		{"per seed, concurrent", 3, true, 8, map[string]int{"a": 3, "b": 3}, 6},
+		{"per seed, unlimited", 0, true, 8, map[string]int{"a": 10, "b": 10}, 20},
+		{"whole crawl, concurrent", 4, false, 8, nil, 4},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var queue []FrontierItem
+			for i := 0; i < 10; i++ {
+				for _, seed := range []string{"a", "b"} {
+					queue = append(queue, FrontierItem{URL: fmt.Sprintf("https://%s.com/%d", seed, i), Seed: seed, Depth: i})
+				}
+			}
+			b := newPageBudget(c.max, c.perSeed)
+			var mu sync.Mutex
+			crawled := map[string]int{}
+			failed := map[string]bool{}
+			var wg sync.WaitGroup
+			for w := 0; w < c.workers; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						mu.Lock()
+						if len(queue) == 0 {
+							mu.Unlock()
+							return
+						}
+						item := queue[0]
+						queue = queue[1:]
+						if !b.left(item) {
+							mu.Unlock()
+							continue
+						}
+						b.take(item)
+						mu.Unlock()
+
+						runtime.Gosched()
+
+						mu.Lock()
+						if item.Depth%3 == 0 && !failed[item.URL] {
+							failed[item.URL] = true
+							b.giveBack(item)
+							queue = append(queue, item)
+						} else {
+							crawled[item.Seed]++
+						}
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			total := 0
+			for _, n := range crawled {
+				total += n
+			}
+			if total != c.total {
+				t.Errorf("crawled %d pages, want %d (%v)", total, c.total, crawled)
+			}
+			if c.want != nil && !reflect.DeepEqual(crawled, c.want) {
+				t.Errorf("crawled %v, want %v", crawled, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// stealthFlags hide the launch-time automation markers: the
+// "controlled by automated test software" mode, the AutomationControlled
+// blink feature behind navigator.webdriver, and the old headless mode with
+// its 800x600 window. They only apply to browsers jscout launches.
+func stealthFlags(headless bool) []chromedp.ExecAllocatorOption {
+	opts := []chromedp.ExecAllocatorOption{
+		chromedp.Flag("enable-automation", false),
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
+		chromedp.WindowSize(1920, 1080),
+	}
+	if headless {
+		opts = append(opts, chromedp.Flag("headless", "new"))
+	}
+	return opts
+}
+
+// stealthScript runs before any page script and patches the fingerprints
+// headless Chrome is usually recognised by: navigator.webdriver, empty
+// plugins and languages, a missing window.chrome, the notification
+// permission mismatch and the SwiftShader WebGL vendor. navigator.languages
+// follows the crawl's locale (__JSCOUT_LANGUAGES__ is replaced with its JSON
+// list), so it agrees with Accept-Language and navigator.language.
+const stealthScript = `
+(function() {
+	const define = (obj, prop, get) => {
+		try { Object.defineProperty(obj, prop, { get, configurable: true }); } catch(e) {}
+	};
+	define(Navigator.prototype, 'webdriver', () => undefined);
+	const languages = Object.freeze(__JSCOUT_LANGUAGES__);
+	define(Navigator.prototype, 'languages', () => languages);
+	if (!navigator.hardwareConcurrency || navigator.hardwareConcurrency < 2) {
+		define(Navigator.prototype, 'hardwareConcurrency', () => 8);
+	}
+
+	// A PluginArray-like list with the PDF viewers every desktop Chrome has
+	if (navigator.plugins.length === 0) {
+		const mime = { type: 'application/pdf', suffixes: 'pdf', description: 'Portable Document Format' };
+		const plugins = ['PDF Viewer', 'Chrome PDF Viewer', 'Chromium PDF Viewer', 'Microsoft Edge PDF Viewer', 'WebKit built-in PDF'].map(name => {
+			const p = { name, filename: 'internal-pdf-viewer', description: 'Portable Document Format', length: 1, 0: mime };
+			p.item = i => (i === 0 ? mime : null);
+			p.namedItem = t => (t === mime.type ? mime : null);
+			return p;
+		});
+		const list = Object.assign([], plugins);
+		list.item = i => plugins[i] || null;
+		list.namedItem = n => plugins.find(p => p.name === n) || null;
+		list.refresh = () => {};
+		Object.setPrototypeOf(list, PluginArray.prototype);
+		define(Navigator.prototype, 'plugins', () => list);
+		const mimes = Object.assign([], [mime]);
+		mimes.item = i => (i === 0 ? mime : null);
+		mimes.namedItem = t => (t === mime.type ? mime : null);
+		Object.setPrototypeOf(mimes, MimeTypeArray.prototype);
+		define(Navigator.prototype, 'mimeTypes', () => mimes);
+	}
+
+	if (!window.chrome) {
+		Object.defineProperty(window, 'chrome', { value: {}, writable: true, configurable: true });
+	}
+	if (!window.chrome.runtime) {
+		window.chrome.runtime = {};
+	}
+
+	// Headless reports "denied" for Notification but "prompt" to the Permissions API
+	try {
+		const query = navigator.permissions.query.bind(navigator.permissions);
+		navigator.permissions.query = (params) =>
+			params && params.name === 'notifications'
+				? Promise.resolve({ state: Notification.permission === 'denied' ? 'prompt' : Notification.permission, onchange: null })
+				: query(params);
+	} catch(e) {}
+
+	// UNMASKED_VENDOR_WEBGL / UNMASKED_RENDERER_WEBGL
+	const patchGL = (proto) => {
+		if (!proto) return;
+		const getParameter = proto.getParameter;
+		proto.getParameter = function(p) {
+			if (p === 37445) return 'Intel Inc.';
+			if (p === 37446) return 'Intel Iris OpenGL Engine';
+			return getParameter.apply(this, arguments);
+		};
+	};
+	patchGL(window.WebGLRenderingContext && WebGLRenderingContext.prototype);
+	patchGL(window.WebGL2RenderingContext && WebGL2RenderingContext.prototype);
+})();
+`
+
+// installStealth registers stealthScript for every document of the tab,
+// reporting the languages of loc.
+func installStealth(ctx context.Context, loc LocaleProfile) (page.ScriptIdentifier, error) {
+	langs, err := json.Marshal(loc.languages())
+	if err != nil {
+		return "", err
+	}
+	script := strings.Replace(stealthScript, "__JSCOUT_LANGUAGES__", string(langs), 1)
+	var id page.ScriptIdentifier
+	err = chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		id, err = page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
+		return err
+	}))
+	return id, err
+}
+
+// stealthUserAgent returns the browser's own User-Agent without the
+// "HeadlessChrome" product token, or "" if it cannot be read.
+func stealthUserAgent(ctx context.Context) string {
+	return strings.ReplaceAll(browserUserAgent(ctx), "HeadlessChrome", "Chrome")
+}
+
+// browserUserAgent returns the browser's own User-Agent, or "" if it cannot
+// be read.
+func browserUserAgent(ctx context.Context) string {
+	var ua string
+	_ = chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		_, _, _, ua, _, err = browser.GetVersion().Do(ctx)
+		return err
+	}))
+	return ua
+}
+
+// challengeScript names the bot-challenge or block page the document is,
+// if it is one. Markers are checked on the document itself, so a login
+// form with an embedded captcha does not count.
+const challengeScript = `
+(function() {
+	const html = document.documentElement ? document.documentElement.outerHTML.slice(0, 200000) : '';
+	const title = (document.title || '').toLowerCase();
+	const has = (sel) => { try { return !!document.querySelector(sel); } catch(e) { return false; } };
+	const short = (document.body ? document.body.innerText.length : 0) < 3000;
+	if (has('#challenge-form, #challenge-running, #cf-challenge-running, #cf-please-wait') ||
+		/\/cdn-cgi\/challenge-platform\//.test(html) && (title.includes('just a moment') || title.includes('attention required'))) {
+		return 'cloudflare';
+	}
+	if (title.includes('attention required') && /cloudflare/i.test(html)) return 'cloudflare';
+	if (/captcha-delivery\.com|geo\.captcha-delivery/i.test(html) && short) return 'datadome';
+	if (has('#px-captcha') || /_pxCaptcha|px-captcha/i.test(html) && short) return 'perimeterx';
+	if (/_Incapsula_Resource|incapsula incident id/i.test(html) && short) return 'imperva';
+	if (/awsWafIntegration|\.awswaf\.com|aws-waf-token/i.test(html) && short) return 'aws-waf';
+	if (title === 'access denied' && /reference\s*#\s*[0-9a-f.]+/i.test(html) && /akamai|edgesuite/i.test(html)) return 'akamai';
+	if (/sec-if-cpt-container|bm-verify/i.test(html) && short) return 'akamai';
+	if (/KPSDK|x-kpsdk/.test(html) && short) return 'kasada';
+	if (/hcaptcha\.com\/1\/api\.js/i.test(html) && short && !has('form input[type=password]')) return 'hcaptcha';
+	if (/recaptcha\/(api|enterprise)\.js/i.test(html) && short && !has('form input[type=password]') &&
+		/(verify|are you (a )?human|unusual traffic|robot)/i.test(document.body ? document.body.innerText : '')) return 'recaptcha';
+	return '';
+})()
+`
+
+// detectChallenge reports which known bot challenge, if any, the page in
+// ctx shows instead of its content.
+func detectChallenge(ctx context.Context) string {
+	var name string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(challengeScript, &name)); err != nil {
+		return ""
+	}
+	return name
+}
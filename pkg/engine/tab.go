@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// tabResetTimeout bounds the navigation to about:blank between pages.
+const tabResetTimeout = 5 * time.Second
+
+// workerTab is a tab a worker keeps across pages (Options.TabReuse) instead
+// of creating a target per page. Each page runs in a child context of the
+// tab, so its event listeners go away with it; the tab is reset to
+// about:blank in between.
+type workerTab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	b      *pooledBrowser
+	gen    int    // browser generation the tab was opened in
+	key    string // isolation key of its browser context
+	uses   int
+}
+
+func openTab(b *pooledBrowser, key string, bcID cdp.BrowserContextID) *workerTab {
+	var opts []chromedp.ContextOption
+	if bcID != "" {
+		opts = append(opts, chromedp.WithExistingBrowserContext(bcID))
+	}
+	ctx, cancel := chromedp.NewContext(b.ctx, opts...)
+	return &workerTab{ctx: ctx, cancel: cancel, b: b, gen: b.gen, key: key}
+}
+
+// usableFor reports whether the tab can serve a page on b in the browser
+// context for key.
+func (t *workerTab) usableFor(b *pooledBrowser, key string) bool {
+	return t.b == b && t.gen == b.gen && t.key == key && t.ctx.Err() == nil
+}
+
+// reset leaves the previous page so nothing of it keeps running.
+func (t *workerTab) reset() bool {
+	ctx, cancel := context.WithTimeout(t.ctx, tabResetTimeout)
+	defer cancel()
+	return chromedp.Run(ctx, chromedp.Navigate("about:blank")) == nil
+}
+
+func (t *workerTab) close() { t.cancel() }
+
+// removeScriptOnClose unregisters a new-document script when the page is
+// done, so a reused tab does not accumulate them. ctx may already be
+// cancelled by then; the tab itself is still open.
+func removeScriptOnClose(ctx context.Context, id page.ScriptIdentifier) {
+	rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tabResetTimeout)
+	defer cancel()
+	_ = chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return page.RemoveScriptToEvaluateOnNewDocument(id).Do(ctx)
+	}))
+}
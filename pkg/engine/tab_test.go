@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyinnove/jscout/utils"
+)
+
+// benchPages is how many linked pages the tab reuse benchmark crawls.
+const benchPages = 40
+
+// BenchmarkTabReuse crawls a local site once per iteration with a new tab
+// per page and with reused tabs, reporting pages/s for each. It needs a
+// local Chrome and is skipped without one:
+//
+//	go test ./pkg/engine -run '^$' -bench TabReuse -benchtime 3x
+func BenchmarkTabReuse(b *testing.B) {
+	chrome := utils.DetectChromePath()
+	if chrome == "" {
+		b.Skip("no Chrome found")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		var sb strings.Builder
+		sb.WriteString(`<html><body><script src="/static/main.js"></script>`)
+		for i := 0; i < benchPages; i++ {
+			fmt.Fprintf(&sb, `<a href="/page/%d">%d</a>`, i, i)
+		}
+		sb.WriteString(`</body></html>`)
+		fmt.Fprint(w, sb.String())
+	})
+	mux.HandleFunc("/page/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><h1>%s</h1><script src="/static%s.js"></script></body></html>`, r.URL.Path, r.URL.Path)
+	})
+	mux.HandleFunc("/static/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		fmt.Fprint(w, "var loaded = true;")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, reuse := range []int{1, 50} {
+		b.Run(fmt.Sprintf("reuse=%d", reuse), func(b *testing.B) {
+			var pages int
+			var elapsed time.Duration
+			for i := 0; i < b.N; i++ {
+				eng := New(Options{
+					AllowedHosts: []string{"127.0.0.1"},
+					ChromePath:   chrome,
+					Headless:     true,
+					TabReuse:     reuse,
+					BlockTypes:   []string{"none"},
+					PageTimeout:  30 * time.Second,
+					WaitUntil:    WaitLoad,
+					MaxDepth:     1,
+					Concurrency:  4,
+				})
+				start := time.Now()
+				if _, err := eng.Crawl([]string{srv.URL + "/"}); err != nil {
+					b.Fatalf("crawl: %v", err)
+				}
+				elapsed += time.Since(start)
+				pages += len(eng.Pages())
+			}
+			b.ReportMetric(float64(pages)/elapsed.Seconds(), "pages/s")
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// Wait strategies accepted by Options.WaitUntil. They decide when a page
+// counts as loaded, before it is interacted with; WaitAfterLoad caps the
+// wait (selector and network strategies) after the load event.
+const (
+	WaitLoad             = "load"             // the load event
+	WaitDOMContentLoaded = "domcontentloaded" // DOMContentLoaded, without waiting for subresources
+	WaitNetworkIdle0     = "networkidle0"     // no requests in flight for networkQuietWindow (default)
+	WaitNetworkIdle2     = "networkidle2"     // at most two requests in flight for networkQuietWindow
+	WaitSelectorPrefix   = "selector:"        // selector:<css>, until the element is in the DOM
+	WaitFixedPrefix      = "fixed:"           // fixed:<duration>, e.g. fixed:2s
+)
+
+const (
+	// networkQuietWindow is how long the network must stay quiet to count
+	// as idle.
+	networkQuietWindow = 500 * time.Millisecond
+	// longPollAfter is how long a fetch/XHR may stay open before it is
+	// treated as long-polling and no longer keeps the page busy.
+	longPollAfter = 5 * time.Second
+
+	waitSelector = "selector"
+	waitFixed    = "fixed"
+)
+
+// WaitStrategy is a parsed Options.WaitUntil value.
+type WaitStrategy struct {
+	Kind     string        // one of the Wait* kinds; selector and fixed without the colon
+	Selector string        // for selector:<css>
+	Duration time.Duration // for fixed:<dur>
+}
+
+// ParseWaitStrategy parses a WaitUntil value; "" is networkidle0.
+func ParseWaitStrategy(s string) (WaitStrategy, error) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	switch {
+	case lower == "":
+		return WaitStrategy{Kind: WaitNetworkIdle0}, nil
+	case lower == WaitLoad, lower == WaitDOMContentLoaded, lower == WaitNetworkIdle0, lower == WaitNetworkIdle2:
+		return WaitStrategy{Kind: lower}, nil
+	case strings.HasPrefix(lower, WaitSelectorPrefix):
+		sel := strings.TrimSpace(s[len(WaitSelectorPrefix):])
+		if sel == "" {
+			return WaitStrategy{}, fmt.Errorf("wait strategy %q: empty selector", s)
+		}
+		return WaitStrategy{Kind: waitSelector, Selector: sel}, nil
+	case strings.HasPrefix(lower, WaitFixedPrefix):
+		d, err := time.ParseDuration(strings.TrimSpace(s[len(WaitFixedPrefix):]))
+		if err != nil || d < 0 {
+			return WaitStrategy{}, fmt.Errorf("wait strategy %q: invalid duration", s)
+		}
+		return WaitStrategy{Kind: waitFixed, Duration: d}, nil
+	}
+	return WaitStrategy{}, fmt.Errorf("unknown wait strategy: %s (use load|domcontentloaded|networkidle0|networkidle2|selector:<css>|fixed:<dur>)", s)
+}
+
+// allowedInflight is how many requests may stay in flight while the
+// network still counts as idle.
+func (w WaitStrategy) allowedInflight() int {
+	if w.Kind == WaitNetworkIdle2 {
+		return 2
+	}
+	return 0
+}
+
+// navigate loads pageURL and returns once the strategy's navigation event
+// has fired: DOMContentLoaded for domcontentloaded, the load event for the
+// others.
+func (w WaitStrategy) navigate(ctx context.Context, pageURL string) error {
+	if w.Kind != WaitDOMContentLoaded {
+		return chromedp.Run(ctx, chromedp.Navigate(pageURL))
+	}
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	domReady := make(chan struct{})
+	var once sync.Once
+	chromedp.ListenTarget(lctx, func(ev interface{}) {
+		if _, ok := ev.(*page.EventDomContentEventFired); ok {
+			once.Do(func() { close(domReady) })
+		}
+	})
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, errorText, _, err := page.Navigate(pageURL).Do(ctx)
+		if err == nil && errorText != "" {
+			err = fmt.Errorf("page load error %s", errorText)
+		}
+		return err
+	}))
+	if err != nil {
+		return err
+	}
+	select {
+	case <-domReady:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// settle waits, after navigation, for the strategy's condition for at most
+// maxWait. Failing to meet it is not an error: the page is used as it is.
+func (w WaitStrategy) settle(ctx context.Context, idle *idleTracker, maxWait time.Duration) {
+	switch w.Kind {
+	case WaitNetworkIdle0, WaitNetworkIdle2:
+		if maxWait > 0 {
+			idle.wait(ctx, maxWait, w.allowedInflight())
+		}
+	case waitSelector:
+		if maxWait > 0 {
+			sctx, cancel := context.WithTimeout(ctx, maxWait)
+			defer cancel()
+			_ = chromedp.Run(sctx, chromedp.WaitReady(w.Selector, chromedp.ByQuery))
+		}
+	case waitFixed:
+		sleepCtx(ctx, w.Duration)
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// idleTracker counts a page's requests in flight from network events.
+// Waiters are woken on every event instead of polling; websockets,
+// server-sent events, beacons and long-polling requests are not counted.
+type idleTracker struct {
+	mu       sync.Mutex
+	inflight map[network.RequestID]trackedRequest
+	changed  chan struct{} // closed and replaced on every counted event
+}
+
+type trackedRequest struct {
+	started  time.Time
+	pollable bool // fetch/XHR, which may turn out to be long-polling
+}
+
+// newIdleTracker starts tracking the requests of the tab in ctx; it stops
+// when ctx is done.
+func newIdleTracker(ctx context.Context) *idleTracker {
+	t := &idleTracker{inflight: make(map[network.RequestID]trackedRequest), changed: make(chan struct{})}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			if e.Request == nil || ignoredForIdle(e.Type) {
+				return
+			}
+			pollable := e.Type == network.ResourceTypeFetch || e.Type == network.ResourceTypeXHR
+			t.update(func() { t.inflight[e.RequestID] = trackedRequest{started: time.Now(), pollable: pollable} })
+		case *network.EventLoadingFinished:
+			t.done(e.RequestID)
+		case *network.EventLoadingFailed:
+			t.done(e.RequestID)
+		}
+	})
+	return t
+}
+
+func ignoredForIdle(typ network.ResourceType) bool {
+	switch typ {
+	case network.ResourceTypeWebSocket, network.ResourceTypeEventSource, network.ResourceTypePing:
+		return true
+	}
+	return false
+}
+
+func (t *idleTracker) done(id network.RequestID) {
+	t.mu.Lock()
+	_, ok := t.inflight[id]
+	t.mu.Unlock()
+	if ok {
+		t.update(func() { delete(t.inflight, id) })
+	}
+}
+
+// update applies fn under the lock and wakes the waiters.
+func (t *idleTracker) update(fn func()) {
+	t.mu.Lock()
+	fn()
+	close(t.changed)
+	t.changed = make(chan struct{})
+	t.mu.Unlock()
+}
+
+// state returns the requests that keep the page busy, the channel closed on
+// the next event, and when the oldest pollable request turns into a
+// long-poll (zero if none will).
+func (t *idleTracker) state() (busy int, changed <-chan struct{}, nextExpiry time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for _, r := range t.inflight {
+		if !r.pollable {
+			busy++
+			continue
+		}
+		expiry := r.started.Add(longPollAfter)
+		if expiry.After(now) {
+			busy++
+			if nextExpiry.IsZero() || expiry.Before(nextExpiry) {
+				nextExpiry = expiry
+			}
+		}
+	}
+	return busy, t.changed, nextExpiry
+}
+
+// wait returns true once at most allowed requests have been in flight for
+// networkQuietWindow, or false when maxWait passes or ctx is done first.
+// Every request starting or finishing restarts the quiet window.
+func (t *idleTracker) wait(ctx context.Context, maxWait time.Duration, allowed int) bool {
+	deadline := time.NewTimer(maxWait)
+	defer deadline.Stop()
+	for {
+		busy, changed, nextExpiry := t.state()
+		var wake <-chan time.Time
+		var timer *time.Timer
+		switch {
+		case busy <= allowed:
+			timer = time.NewTimer(networkQuietWindow)
+			wake = timer.C
+		case !nextExpiry.IsZero():
+			timer = time.NewTimer(time.Until(nextExpiry))
+			wake = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return false
+		case <-deadline.C:
+			stopTimer(timer)
+			return false
+		case <-changed:
+			stopTimer(timer)
+		case <-wake:
+			if busy <= allowed {
+				return true
+			}
+			// a request became a long-poll; count again
+		}
+	}
+}
+
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
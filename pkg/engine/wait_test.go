@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestParseWaitStrategy(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    WaitStrategy
+		wantErr bool
+	}{
+		{in: "", want: WaitStrategy{Kind: WaitNetworkIdle0}},
+		{in: "load", want: WaitStrategy{Kind: WaitLoad}},
+		{in: " DOMContentLoaded ", want: WaitStrategy{Kind: WaitDOMContentLoaded}},
+		{in: "networkidle0", want: WaitStrategy{Kind: WaitNetworkIdle0}},
+		{in: "networkidle2", want: WaitStrategy{Kind: WaitNetworkIdle2}},
+		{in: "selector:#App .Ready", want: WaitStrategy{Kind: waitSelector, Selector: "#App .Ready"}},
+		{in: "Selector: main", want: WaitStrategy{Kind: waitSelector, Selector: "main"}},
+		{in: "fixed:2s", want: WaitStrategy{Kind: waitFixed, Duration: 2 * time.Second}},
+		{in: "fixed:0", want: WaitStrategy{Kind: waitFixed}},
+		{in: "selector:", wantErr: true},
+		{in: "fixed:soon", wantErr: true},
+		{in: "fixed:-1s", wantErr: true},
+		{in: "networkidle", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := ParseWaitStrategy(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ParseWaitStrategy(%q): expected error, got %+v", c.in, got)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("ParseWaitStrategy(%q) = %+v, %v; want %+v", c.in, got, err, c.want)
+		}
+	}
+}
+
+func TestIdleTrackerWait(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name     string
+		inflight map[network.RequestID]trackedRequest
+		finish   network.RequestID // finished shortly after waiting starts
+		allowed  int
+		want     bool
+	}{
+		{name: "nothing in flight", want: true},
+		{name: "one request busy", inflight: map[network.RequestID]trackedRequest{"1": {started: now}}, want: false},
+		{name: "networkidle2 tolerates two", allowed: 2, want: true,
+			inflight: map[network.RequestID]trackedRequest{"1": {started: now}, "2": {started: now}}},
+		{name: "request finishes", inflight: map[network.RequestID]trackedRequest{"1": {started: now}}, finish: "1", want: true},
+		{name: "long-poll no longer counts", want: true,
+			inflight: map[network.RequestID]trackedRequest{"1": {started: now.Add(-longPollAfter), pollable: true}}},
+		{name: "fresh fetch counts", want: false,
+			inflight: map[network.RequestID]trackedRequest{"1": {started: now, pollable: true}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			tr := &idleTracker{inflight: map[network.RequestID]trackedRequest{}, changed: make(chan struct{})}
+			for id, r := range c.inflight {
+				tr.inflight[id] = r
+			}
+			if c.finish != "" {
+				time.AfterFunc(100*time.Millisecond, func() { tr.done(c.finish) })
+			}
+			start := time.Now()
+			got := tr.wait(context.Background(), 2*networkQuietWindow, c.allowed)
+			if got != c.want {
+				t.Errorf("wait = %v, want %v", got, c.want)
+			}
+			if got && time.Since(start) < networkQuietWindow {
+				t.Errorf("idle after %v, before the quiet window", time.Since(start))
+			}
+		})
+	}
+}
+
+func TestIgnoredForIdle(t *testing.T) {
+	for typ, want := range map[network.ResourceType]bool{
+		network.ResourceTypeWebSocket:   true,
+		network.ResourceTypeEventSource: true,
+		network.ResourceTypePing:        true,
+		network.ResourceTypeFetch:       false,
+		network.ResourceTypeScript:      false,
+	} {
+		if got := ignoredForIdle(typ); got != want {
+			t.Errorf("ignoredForIdle(%s) = %v, want %v", typ, got, want)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+// Node kinds.
+const (
+	KindPage   = "page"
+	KindScript = "script"
+	KindHost   = "host"
+)
+
+// Edge kinds.
+const (
+	EdgeLinksTo     = "links_to"     // page -> page
+	EdgeLoads       = "loads"        // page -> script
+	EdgeInitiatedBy = "initiated_by" // script -> script or page that caused the load
+	EdgeHostedOn    = "hosted_on"    // page/script -> host
+)
+
+// Node is a page, script or host in the crawl graph.
+type Node struct {
+	ID    string            `json:"id"`
+	Kind  string            `json:"kind"`
+	Label string            `json:"label"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+// Edge is a directed relationship between two nodes.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// Graph is the page/script/host structure of a crawl.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+
+	index map[string]int
+	edges map[Edge]struct{}
+}
+
+// ID helpers keep node identifiers stable across formats.
+func PageID(u string) string   { return KindPage + ":" + u }
+func ScriptID(u string) string { return KindScript + ":" + u }
+func HostID(h string) string   { return KindHost + ":" + h }
+
+// Build assembles a graph from page and JS records. Pages linked to but not
+// visited (for instance beyond max depth) still appear as page nodes.
+func Build(pages []*model.PageRecord, records []*model.JSRecord) *Graph {
+	g := &Graph{index: map[string]int{}, edges: map[Edge]struct{}{}}
+
+	for _, p := range pages {
+		attrs := map[string]string{
+			"depth":    strconv.Itoa(p.Depth),
+			"status":   strconv.FormatInt(p.Status, 10),
+			"js_count": strconv.Itoa(p.JSCount),
+		}
+		if p.Title != "" {
+			attrs["title"] = p.Title
+		}
+		if p.ErrorKind != "" {
+			attrs["error_kind"] = p.ErrorKind
+		}
+		g.addPage(p.URL, attrs)
+	}
+	for _, p := range pages {
+		for _, l := range p.Links {
+			g.addPage(l, nil)
+			g.addEdge(PageID(p.URL), PageID(l), EdgeLinksTo)
+		}
+	}
+	scripts := make(map[string]string, len(records)) // raw or normalized URL -> node URL
+	for _, r := range records {
+		g.addScript(r.JSURL, map[string]string{
+			"status": strconv.FormatInt(r.Status, 10),
+			"mime":   r.MIME,
+		})
+		g.addPage(r.SourcePage, nil)
+		g.addEdge(PageID(r.SourcePage), ScriptID(r.JSURL), EdgeLoads)
+		scripts[r.JSURL] = r.JSURL
+		if r.RawURL != "" {
+			scripts[r.RawURL] = r.JSURL
+		}
+	}
+	// Initiators that are captured scripts link script -> script; anything
+	// else (typically the parsing document) is treated as a page.
+	for _, r := range records {
+		if r.InitiatorURL == "" {
+			continue
+		}
+		if s, ok := scripts[r.InitiatorURL]; ok {
+			g.addEdge(ScriptID(r.JSURL), ScriptID(s), EdgeInitiatedBy)
+			continue
+		}
+		if s, ok := scripts[stripQuery(r.InitiatorURL)]; ok {
+			g.addEdge(ScriptID(r.JSURL), ScriptID(s), EdgeInitiatedBy)
+			continue
+		}
+		g.addPage(r.InitiatorURL, nil)
+		g.addEdge(ScriptID(r.JSURL), PageID(r.InitiatorURL), EdgeInitiatedBy)
+	}
+	return g
+}
+
+func stripQuery(u string) string {
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		return u[:i]
+	}
+	return u
+}
+
+func (g *Graph) addNode(n Node) {
+	if i, ok := g.index[n.ID]; ok {
+		// Merge attributes learned from later records
+		for k, v := range n.Attrs {
+			if g.Nodes[i].Attrs == nil {
+				g.Nodes[i].Attrs = map[string]string{}
+			}
+			g.Nodes[i].Attrs[k] = v
+		}
+		return
+	}
+	g.index[n.ID] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, n)
+}
+
+func (g *Graph) addEdge(from, to, kind string) {
+	e := Edge{From: from, To: to, Kind: kind}
+	if _, ok := g.edges[e]; ok {
+		return
+	}
+	g.edges[e] = struct{}{}
+	g.Edges = append(g.Edges, e)
+}
+
+func (g *Graph) addPage(u string, attrs map[string]string) {
+	g.addNode(Node{ID: PageID(u), Kind: KindPage, Label: u, Attrs: attrs})
+	g.addHostEdge(PageID(u), u)
+}
+
+func (g *Graph) addScript(u string, attrs map[string]string) {
+	g.addNode(Node{ID: ScriptID(u), Kind: KindScript, Label: u, Attrs: attrs})
+	g.addHostEdge(ScriptID(u), u)
+}
+
+func (g *Graph) addHostEdge(id, raw string) {
+	pu, err := url.Parse(raw)
+	if err != nil || pu.Host == "" {
+		return
+	}
+	h := strings.ToLower(pu.Host)
+	g.addNode(Node{ID: HostID(h), Kind: KindHost, Label: h})
+	g.addEdge(id, HostID(h), EdgeHostedOn)
+}
+
+// FormatFromPath infers the graph format from a file extension, defaulting to json.
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dot", ".gv":
+		return "dot"
+	case ".graphml", ".xml":
+		return "graphml"
+	default:
+		return "json"
+	}
+}
+
+// Write renders the graph as dot, graphml or json.
+func Write(w io.Writer, format string, g *Graph) error {
+	switch strings.ToLower(format) {
+	case "dot", "gv":
+		return g.WriteDOT(w)
+	case "graphml":
+		return g.WriteGraphML(w)
+	case "json":
+		return g.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown graph format: %s", format)
+	}
+}
+
+// WriteJSON writes the graph as a {"nodes": [...], "edges": [...]} document.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
+
+var dotShapes = map[string]string{KindPage: "box", KindScript: "ellipse", KindHost: "hexagon"}
+
+// WriteDOT writes the graph in Graphviz DOT syntax.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph jscout {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(bw, "  %s [label=%s, shape=%s, kind=%s%s];\n",
+			dotQuote(n.ID), dotQuote(n.Label), dotShapes[n.Kind], dotQuote(n.Kind), dotAttrs(n.Attrs))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "  %s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Kind))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func dotAttrs(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&sb, ", %s=%s", k, dotQuote(attrs[k]))
+	}
+	return sb.String()
+}
+
+type graphmlKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+type graphmlDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphmlNode `xml:"node"`
+		Edges       []graphmlEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// WriteGraphML writes the graph as GraphML with kind, label and node
+// attributes declared as string keys.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphmlDoc{XMLNS: "http://graphml.graphdrawing.org/xmlns"}
+	doc.Graph.ID = "jscout"
+	doc.Graph.EdgeDefault = "directed"
+
+	attrNames := map[string]struct{}{}
+	for _, n := range g.Nodes {
+		for k := range n.Attrs {
+			attrNames[k] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(attrNames))
+	for k := range attrNames {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	doc.Keys = append(doc.Keys,
+		graphmlKey{ID: "kind", For: "node", Name: "kind", Type: "string"},
+		graphmlKey{ID: "label", For: "node", Name: "label", Type: "string"},
+		graphmlKey{ID: "edge_kind", For: "edge", Name: "kind", Type: "string"},
+	)
+	for _, k := range names {
+		doc.Keys = append(doc.Keys, graphmlKey{ID: "n_" + k, For: "node", Name: k, Type: "string"})
+	}
+
+	for _, n := range g.Nodes {
+		gn := graphmlNode{ID: n.ID, Data: []graphmlData{{Key: "kind", Value: n.Kind}, {Key: "label", Value: n.Label}}}
+		for _, k := range names {
+			if v, ok := n.Attrs[k]; ok {
+				gn.Data = append(gn.Data, graphmlData{Key: "n_" + k, Value: v})
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gn)
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphmlEdge{
+			Source: e.From, Target: e.To,
+			Data: []graphmlData{{Key: "edge_kind", Value: e.Kind}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
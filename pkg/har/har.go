@@ -0,0 +1,221 @@
+// Package har models HTTP Archive 1.2 documents
+// (http://www.softwareishard.com/blog/har-12-spec/) for the crawl audit trail.
+package har
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the HAR format version written by jscout.
+const Version = "1.2"
+
+// HAR is the root of an HTTP Archive document.
+type HAR struct {
+	Log *Log `json:"log"`
+}
+
+// Log holds every page and request of a crawl.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Pages   []Page  `json:"pages"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator names the application that produced the archive.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Page is one page load; entries refer to it by ID.
+type Page struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	ID              string      `json:"id"`
+	Title           string      `json:"title"`
+	PageTimings     PageTimings `json:"pageTimings"`
+}
+
+// PageTimings are milliseconds since StartedDateTime, -1 when unknown.
+type PageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+// Entry is one request/response exchange.
+type Entry struct {
+	PageRef         string   `json:"pageref,omitempty"`
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+	ServerIPAddress string   `json:"serverIPAddress,omitempty"`
+
+	// Chrome DevTools extensions, understood by most HAR consumers
+	ResourceType string `json:"_resourceType,omitempty"`
+	Error        string `json:"_error,omitempty"`
+}
+
+// Request describes the request as sent.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+// Response describes the response as received.
+type Response struct {
+	Status      int64       `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+// NameValue is a header or query string pair.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Cookie is a request or response cookie.
+type Cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is the request body.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content is the response body; Text is empty unless bodies are captured.
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Timings are the request phases in milliseconds, -1 when not applicable.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// NewLog returns an empty log created by jscout.
+func NewLog() *Log {
+	return &Log{
+		Version: Version,
+		Creator: Creator{Name: "jscout", Version: creatorVersion()},
+		Pages:   []Page{},
+		Entries: []Entry{},
+	}
+}
+
+func creatorVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" {
+		return bi.Main.Version
+	}
+	return "(devel)"
+}
+
+// Time formats a timestamp the way StartedDateTime expects. The fixed width
+// UTC layout also makes the strings sort chronologically.
+func Time(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// Append adds the pages and entries of another log.
+func (l *Log) Append(o *Log) {
+	if o == nil {
+		return
+	}
+	l.Pages = append(l.Pages, o.Pages...)
+	l.Entries = append(l.Entries, o.Entries...)
+}
+
+// Write encodes the log as a HAR document with pages and entries in start
+// order, as HAR viewers expect.
+func Write(w io.Writer, l *Log) error {
+	if l == nil {
+		l = NewLog()
+	}
+	sort.SliceStable(l.Pages, func(i, j int) bool { return l.Pages[i].StartedDateTime < l.Pages[j].StartedDateTime })
+	sort.SliceStable(l.Entries, func(i, j int) bool { return l.Entries[i].StartedDateTime < l.Entries[j].StartedDateTime })
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(HAR{Log: l})
+}
+
+// Headers converts a header map into sorted name/value pairs. Chrome joins
+// repeated headers (Set-Cookie) with newlines; they are split back apart.
+func Headers(m map[string]any) []NameValue {
+	out := make([]NameValue, 0, len(m))
+	for k, v := range m {
+		switch vv := v.(type) {
+		case string:
+			for _, line := range strings.Split(vv, "\n") {
+				out = append(out, NameValue{Name: k, Value: line})
+			}
+		default:
+			b, _ := json.Marshal(vv)
+			out = append(out, NameValue{Name: k, Value: string(b)})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// RequestCookies parses the Cookie headers of a request.
+func RequestCookies(headers []NameValue) []Cookie {
+	out := []Cookie{}
+	h := http.Header{}
+	for _, nv := range headers {
+		if strings.EqualFold(nv.Name, "cookie") {
+			h.Add("Cookie", nv.Value)
+		}
+	}
+	for _, c := range (&http.Request{Header: h}).Cookies() {
+		out = append(out, Cookie{Name: c.Name, Value: c.Value})
+	}
+	return out
+}
+
+// ResponseCookies parses the Set-Cookie headers of a response.
+func ResponseCookies(headers []NameValue) []Cookie {
+	out := []Cookie{}
+	for _, nv := range headers {
+		if !strings.EqualFold(nv.Name, "set-cookie") {
+			continue
+		}
+		if c, err := http.ParseSetCookie(nv.Value); err == nil {
+			out = append(out, Cookie{Name: c.Name, Value: c.Value})
+		}
+	}
+	return out
+}
@@ -1,11 +1,69 @@
 package model
 
-// JSRecord represents a discovered JavaScript resource.
+// Observation values for JSRecord.Observation.
+const (
+    Observed       = "observed"        // the browser fetched the script
+    ReferencedOnly = "referenced-only" // found in the DOM/HTML but never fetched
+)
+
+// JSRecord represents a discovered JavaScript resource. All fields are
+// always serialised so JSONL consumers see a stable schema.
 type JSRecord struct {
-    JSURL      string `json:"js_url"`
-    SourcePage string `json:"source_page"`
-    Status     int64  `json:"status"`
-    MIME       string `json:"mime"`
-    FromCache  bool   `json:"from_cache"`
+    JSURL        string `json:"js_url"`  // normalized per the URL policy, used for dedupe
+    RawURL       string `json:"raw_url"` // as requested, including the query string
+    SourcePage   string `json:"source_page"`
+    Status       int64  `json:"status"` // 0 for referenced-only records
+    MIME         string `json:"mime"`
+    FromCache    bool   `json:"from_cache"`
+    Observation  string `json:"observation"`   // observed|referenced-only
+    ResourceKind string `json:"resource_kind"` // javascript|module|commonjs|jsonp|typescript|wasm
+    Trigger      string `json:"trigger"`       // UI action that caused the load, if any
+    Profile      string `json:"profile"`       // device profile of the crawl, if any
+    Locale       string `json:"locale"`        // locale profile of the crawl, if any
+
+    // Initiator: what caused the browser to request this script.
+    InitiatorType     string `json:"initiator_type"`     // parser|script|preload|other|...
+    InitiatorURL      string `json:"initiator_url"`      // script or document that issued the load
+    InitiatorFunction string `json:"initiator_function"` // top stack frame function, if known
+    InitiatorLine     int64  `json:"initiator_line"`     // 1-based
+    InitiatorColumn   int64  `json:"initiator_column"`   // 1-based
+
+    // Response metadata (observed records only)
+    Headers     map[string]string `json:"headers"`      // server, caching and CDN headers, lower-cased
+    EncodedSize int64             `json:"encoded_size"` // bytes on the wire
+    DecodedSize int64             `json:"decoded_size"` // bytes after content decoding
+    TTFBMS      float64           `json:"ttfb_ms"`      // request start until response headers
+    DurationMS  float64           `json:"duration_ms"`  // request start until loading finished
+
+    // DOM attributes of the referencing <script> or preload <link>
+    Module      bool   `json:"module"`
+    Async       bool   `json:"async"`
+    Defer       bool   `json:"defer"`
+    Integrity   string `json:"integrity"`
+    CrossOrigin string `json:"crossorigin"`
 }
 
+// PageRecord describes one visited page, successful or not.
+type PageRecord struct {
+    URL        string   `json:"url"`
+    FinalURL   string   `json:"final_url,omitempty"`
+    Status     int64    `json:"status"`
+    Title      string   `json:"title,omitempty"`
+    Depth      int      `json:"depth"`
+    Parent     string   `json:"parent,omitempty"`
+    Seed       string   `json:"seed,omitempty"`      // seed the page was reached from
+    Redirects  []string `json:"redirects,omitempty"` // hops after the requested URL, in order
+    Links      []string `json:"links,omitempty"`     // in-scope pages linked from this page
+    Forms      []string `json:"forms,omitempty"`     // in-scope form targets (actions) on this page
+    JSCount    int      `json:"js_count"`
+    Blocked    int      `json:"blocked,omitempty"` // requests failed by the resource blocking policy
+    LoadMS     int64    `json:"load_ms"`  // navigation until body is ready
+    IdleMS     int64    `json:"idle_ms"`  // body ready until the network went idle
+    TotalMS    int64    `json:"total_ms"` // whole page visit including interactions
+    ErrorKind  string   `json:"error_kind,omitempty"` // timeout|dns|tls|network|browser
+    ErrorCode  string   `json:"error_code,omitempty"` // Chrome net::ERR_* code, if any
+    Error      string   `json:"error,omitempty"`
+    Challenge  string   `json:"challenge,omitempty"` // bot challenge shown instead of the page: cloudflare|akamai|datadome|perimeterx|imperva|aws-waf|kasada|hcaptcha|recaptcha
+    Profile    string   `json:"profile,omitempty"`   // device profile the page was crawled as
+    Locale     string   `json:"locale,omitempty"`    // locale profile the page was crawled under
+}
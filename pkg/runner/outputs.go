@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cyinnove/jscout/utils"
+)
+
+// outputSpec is one -o value: a format and a path ("-" for STDOUT).
+type outputSpec struct {
+	Format string
+	Path   string
+}
+
+// parseOutputSpec splits "format:path". Values without a known format
+// prefix are plain paths written in defaultFormat, so "-o out.txt" and
+// Windows paths such as "C:\out.txt" keep working.
+func parseOutputSpec(v, defaultFormat string) outputSpec {
+	if f, p, ok := strings.Cut(v, ":"); ok && isOutputFormat(f) {
+		return outputSpec{Format: strings.ToLower(f), Path: p}
+	}
+	return outputSpec{Format: strings.ToLower(defaultFormat), Path: v}
+}
+
+// pagesOutputSpec parses --pages-output on its own, independent of --format:
+// a "format:path" prefix wins, then the path's extension, then txt.
+func pagesOutputSpec(v string) (outputSpec, error) {
+	spec := parseOutputSpec(v, "")
+	if spec.Format == "" {
+		switch strings.ToLower(filepath.Ext(spec.Path)) {
+		case ".jsonl", ".ndjson":
+			spec.Format = "jsonl"
+		case ".csv":
+			spec.Format = "csv"
+		default:
+			spec.Format = "txt"
+		}
+	}
+	if _, ok := splitExt(spec.Format); !ok {
+		return outputSpec{}, fmt.Errorf("--pages-output supports txt|jsonl|csv, not %s", spec.Format)
+	}
+	return spec, nil
+}
+
+func isOutputFormat(name string) bool {
+	if strings.EqualFold(name, "sqlite") {
+		return true
+	}
+	_, ok := utils.LookupFormat(name)
+	return ok
+}
+
+// outputSpecs parses and validates every -o value before the crawl starts.
+func (r *Runner) outputSpecs() ([]outputSpec, error) {
+	values := r.Cfg.Outputs
+	if len(values) == 0 && r.Cfg.OutputDir == "" {
+		values = []string{"-"}
+	}
+	specs := make([]outputSpec, 0, len(values))
+	for _, v := range values {
+		spec := parseOutputSpec(v, r.Cfg.Format)
+		switch {
+		case spec.Format == "sqlite":
+			if isStdout(spec.Path) {
+				return nil, fmt.Errorf("sqlite output needs a database path (-o sqlite:jscout.db)")
+			}
+		case !isOutputFormat(spec.Format):
+			return nil, fmt.Errorf("unknown format: %s (use %s|sqlite)", spec.Format, strings.Join(utils.Formats(), "|"))
+		case spec.Format == "template":
+			if _, err := utils.ParseTemplate(r.Cfg.TemplatePath); err != nil {
+				return nil, fmt.Errorf("template: %w", err)
+			}
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
@@ -0,0 +1,33 @@
+package runner
+
+import "testing"
+
+func TestPagesOutputSpec(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    outputSpec
+		wantErr bool
+	}{
+		{in: "p.jsonl", want: outputSpec{Format: "jsonl", Path: "p.jsonl"}},
+		{in: "out/P.CSV", want: outputSpec{Format: "csv", Path: "out/P.CSV"}},
+		{in: "p.ndjson", want: outputSpec{Format: "jsonl", Path: "p.ndjson"}},
+		{in: "pages.out", want: outputSpec{Format: "txt", Path: "pages.out"}},
+		{in: "-", want: outputSpec{Format: "txt", Path: "-"}},
+		{in: "jsonl:-", want: outputSpec{Format: "jsonl", Path: "-"}},
+		{in: "csv:pages.txt", want: outputSpec{Format: "csv", Path: "pages.txt"}},
+		{in: `C:\out\pages.jsonl`, want: outputSpec{Format: "jsonl", Path: `C:\out\pages.jsonl`}},
+		{in: "html:pages.html", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := pagesOutputSpec(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("pagesOutputSpec(%q) = %+v, want an error", c.in, got)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("pagesOutputSpec(%q) = %+v, %v, want %+v", c.in, got, err, c.want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,7 +14,10 @@ import (
 
 	"github.com/cyinnove/jscout/pkg/config"
 	"github.com/cyinnove/jscout/pkg/engine"
+	"github.com/cyinnove/jscout/pkg/graph"
+	"github.com/cyinnove/jscout/pkg/har"
 	"github.com/cyinnove/jscout/pkg/model"
+	"github.com/cyinnove/jscout/pkg/store"
 	"github.com/cyinnove/jscout/utils"
 )
 
@@ -26,6 +30,58 @@ func New(cfg config.Config) *Runner { return &Runner{Cfg: cfg} }
 func (r *Runner) Run() error {
 
 	start := time.Now()
+	if _, err := engine.NewFrontier(r.Cfg.Strategy, nil); err != nil {
+		return err
+	}
+	switch r.Cfg.URLPolicy {
+	case "", utils.URLPolicyStrip, utils.URLPolicyCacheBust, utils.URLPolicyKeep:
+	default:
+		return fmt.Errorf("unknown js url policy: %s (use strip|cachebust|keep)", r.Cfg.URLPolicy)
+	}
+
+	if _, err := engine.ParseWaitStrategy(r.Cfg.WaitUntil); err != nil {
+		return err
+	}
+
+	if _, err := engine.BlockResourceTypes(r.Cfg.BlockTypes); err != nil {
+		return err
+	}
+	if _, err := r.localeProfiles(); err != nil {
+		return err
+	}
+	for _, d := range r.Cfg.Devices {
+		if _, ok := engine.LookupDevice(d); !ok {
+			return fmt.Errorf("unknown device: %s (use %s)", d, strings.Join(engine.DeviceNames(), "|"))
+		}
+	}
+
+	switch strings.ToLower(r.Cfg.Isolation) {
+	case "", engine.IsolationSeed, engine.IsolationWorker, engine.IsolationPage, engine.IsolationNone:
+	default:
+		return fmt.Errorf("unknown isolation: %s (use seed|worker|page|none)", r.Cfg.Isolation)
+	}
+	for _, h := range r.Cfg.Headers {
+		if name, _, ok := strings.Cut(h, ":"); !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid header %q (use \"Name: value\")", h)
+		}
+	}
+
+	outputs, err := r.outputSpecs()
+	if err != nil {
+		return err
+	}
+	if r.Cfg.OutputDir != "" {
+		if _, ok := splitExt(r.Cfg.Format); !ok {
+			return fmt.Errorf("--output-dir supports txt|jsonl|csv, not %s", r.Cfg.Format)
+		}
+	}
+	var pagesOut outputSpec
+	if r.Cfg.PagesOutputPath != "" {
+		if pagesOut, err = pagesOutputSpec(r.Cfg.PagesOutputPath); err != nil {
+			return err
+		}
+	}
+
 	// Collect all seeds
 	seedsRaw := make([]string, 0, len(r.Cfg.SeedsRaw)+4)
 	seedsRaw = append(seedsRaw, r.Cfg.SeedsRaw...)
@@ -108,35 +164,36 @@ func (r *Runner) Run() error {
 
 	// If scope was explicitly provided, use it for all seeds
 	// Otherwise, crawl each seed independently with its own scope
-	var allRecords []*model.JSRecord
-	
-	if len(allowed) > 0 && (r.Cfg.ScopeCSV != "" || r.Cfg.ScopeFile != "") {
-		// Explicit scope provided - crawl all seeds together with combined scope
-		opt := engine.Options{
-			AllowedHosts:  allowed,
-			ChromePath:    r.Cfg.ChromePath,
-			Headless:      r.Cfg.Headless,
-			UserAgent:     r.Cfg.UserAgent,
-			PageTimeout:   time.Duration(r.Cfg.PageTimeoutSec) * time.Second,
-			WaitAfterLoad: time.Duration(r.Cfg.WaitSeconds) * time.Second,
-			MaxDepth:      r.Cfg.MaxDepth,
-			MaxPages:      r.Cfg.MaxPages,
-			Concurrency:   r.Cfg.Concurrency,
-		}
-		eng := engine.New(opt)
-		records, err := eng.Crawl(seeds)
+	harLog := har.NewLog()
+
+	var split *splitWriter
+	if r.Cfg.OutputDir != "" {
+		var splitScope []string
+		if r.Cfg.JSInScope {
+			splitScope = allowed
+		}
+		split, err = newSplitWriter(r.Cfg.OutputDir, r.Cfg.Format, r.Cfg.Unique, r.Cfg.SplitJSHosts, splitScope)
 		if err != nil {
-			return fmt.Errorf("crawl failed: %w", err)
+			return fmt.Errorf("output dir: %w", err)
 		}
-		allRecords = records
-	} else {
-		// No explicit scope - crawl each seed independently with its own scope
+		defer split.Close()
+	}
+	opt := r.engineOptions(allowed)
+	if split != nil {
+		opt.OnPage = split.Page
+	}
+	crawlSeeds := seeds
+	if r.Cfg.ScopeCSV == "" && r.Cfg.ScopeFile == "" {
+		// No explicit scope - crawl each seed independently with its own
+		// scope, all sharing one engine, its workers and browser pool
+		crawlSeeds = make([]string, 0, len(seeds))
+		opt.SeedScopes = make(map[string][]string, len(seeds))
 		for _, seed := range seeds {
 			u, err := url.Parse(seed)
 			if err != nil || u.Host == "" {
 				continue
 			}
-			
+
 			// Build scope for this specific seed
 			seedAllowed := make([]string, 0, 4)
 			baseDomain := utils.ExtractBaseDomain(u.Host)
@@ -145,28 +202,26 @@ func (r *Runner) Run() error {
 			}
 			h := strings.ToLower(u.Host)
 			seedAllowed = append(seedAllowed, h)
-			
-			opt := engine.Options{
-				AllowedHosts:  seedAllowed,
-				ChromePath:    r.Cfg.ChromePath,
-				Headless:      r.Cfg.Headless,
-				UserAgent:     r.Cfg.UserAgent,
-				PageTimeout:   time.Duration(r.Cfg.PageTimeoutSec) * time.Second,
-				WaitAfterLoad: time.Duration(r.Cfg.WaitSeconds) * time.Second,
-				MaxDepth:      r.Cfg.MaxDepth,
-				MaxPages:      r.Cfg.MaxPages,
-				Concurrency:   r.Cfg.Concurrency,
-			}
-			eng := engine.New(opt)
-			records, err := eng.Crawl([]string{seed})
-			if err != nil {
-				logify.Infof("Warning: Failed to crawl %s: %v", seed, err)
-				continue
-			}
-			allRecords = append(allRecords, records...)
+			opt.SeedScopes[seed] = seedAllowed
+			crawlSeeds = append(crawlSeeds, seed)
 		}
 	}
 
+	eng := engine.New(opt)
+	allRecords, err := eng.Crawl(crawlSeeds)
+	if err != nil {
+		if len(allRecords) == 0 {
+			return fmt.Errorf("crawl failed: %w", err)
+		}
+		// keep what was crawled before the browsers gave out
+		logify.Infof("Warning: crawl stopped early: %v", err)
+	}
+	allPages := eng.Pages()
+	harLog.Append(eng.HAR())
+	logRestarts(eng)
+	logChallenges(allPages, r.Cfg.Stealth)
+	logBlocked(allPages)
+
 	// Optional JS host filtering by scope
 	records := allRecords
 	if r.Cfg.JSInScope && len(allowed) > 0 {
@@ -183,34 +238,275 @@ func (r *Runner) Run() error {
 		records = filtered
 	}
 
-	// Write output
-	var out io.Writer
-	var file *os.File
-	if r.Cfg.OutputPath == "-" || r.Cfg.OutputPath == "" {
-		out = os.Stdout
-	} else {
-		if err := utils.EnsureDirOf(r.Cfg.OutputPath); err != nil {
-			return err
+	if split != nil {
+		if err := split.Close(); err != nil {
+			return fmt.Errorf("write output dir: %w", err)
 		}
-		fh, err := os.Create(r.Cfg.OutputPath)
-		if err != nil {
-			return err
+		logify.Infof("Saved per-host output and index.json to %s", r.Cfg.OutputDir)
+	}
+
+	data := utils.OutputData{
+		Records:      records,
+		Pages:        allPages,
+		Seeds:        seeds,
+		Scope:        allowed,
+		Unique:       r.Cfg.Unique,
+		TemplatePath: r.Cfg.TemplatePath,
+	}
+
+	// Write outputs
+	for _, out := range outputs {
+		if out.Format == "sqlite" {
+			if err := r.saveRun(out.Path, start, seeds, allowed, records, allPages); err != nil {
+				return fmt.Errorf("write output: %w", err)
+			}
+			continue
+		}
+		if err := utils.WriteTo(out.Path, func(w io.Writer) error {
+			return utils.Write(w, out.Format, data)
+		}); err != nil {
+			return fmt.Errorf("write %s output: %w", out.Format, err)
+		}
+		if !isStdout(out.Path) {
+			logify.Infof("Saved %d records to %s (%s)", len(records), out.Path, out.Format)
+		}
+	}
+
+	if r.Cfg.PagesOutputPath != "" {
+		if err := utils.WriteTo(pagesOut.Path, func(w io.Writer) error {
+			return utils.WritePages(w, pagesOut.Format, allPages)
+		}); err != nil {
+			return fmt.Errorf("write pages output: %w", err)
+		}
+		if !isStdout(pagesOut.Path) {
+			logify.Infof("Saved %d page records to %s (%s)", len(allPages), pagesOut.Path, pagesOut.Format)
+		}
+	}
+
+
+	if r.Cfg.GraphPath != "" {
+		format := r.Cfg.GraphFormat
+		if format == "" {
+			format = graph.FormatFromPath(r.Cfg.GraphPath)
+		}
+		g := graph.Build(allPages, records)
+		if err := utils.WriteTo(r.Cfg.GraphPath, func(w io.Writer) error {
+			return graph.Write(w, format, g)
+		}); err != nil {
+			return fmt.Errorf("write graph: %w", err)
+		}
+		if !isStdout(r.Cfg.GraphPath) {
+			logify.Infof("Saved crawl graph (%d nodes, %d edges) to %s", len(g.Nodes), len(g.Edges), r.Cfg.GraphPath)
 		}
-		defer fh.Close()
-		file = fh
-		out = fh
 	}
 
-	if err := utils.WriteOutput(out, r.Cfg.Format, r.Cfg.Unique, records); err != nil {
-		return fmt.Errorf("write output: %w", err)
+	if r.Cfg.ReportPath != "" {
+		if err := utils.WriteTo(r.Cfg.ReportPath, func(w io.Writer) error {
+			return utils.Write(w, "html", data)
+		}); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+		if !isStdout(r.Cfg.ReportPath) {
+			logify.Infof("Saved HTML report to %s", r.Cfg.ReportPath)
+		}
 	}
-	if file != nil {
-		logify.Infof("Saved %d records to %s", len(records), r.Cfg.OutputPath)
+
+	if r.Cfg.HARPath != "" {
+		if err := utils.WriteTo(r.Cfg.HARPath, func(w io.Writer) error {
+			return har.Write(w, harLog)
+		}); err != nil {
+			return fmt.Errorf("write har: %w", err)
+		}
+		if !isStdout(r.Cfg.HARPath) {
+			logify.Infof("Saved HAR (%d pages, %d entries) to %s", len(harLog.Pages), len(harLog.Entries), r.Cfg.HARPath)
+		}
 	}
 
+	elapsed := time.Since(start)
+	logify.Infof("Crawl completed in %s (%d pages, %.2f pages/s)", elapsed, len(allPages), float64(len(allPages))/elapsed.Seconds())
+	return nil
+}
 
-	logify.Infof("Crawl completed in %s", time.Since(start))
+// saveRun adds this crawl to the sqlite database at path.
+func (r *Runner) saveRun(path string, start time.Time, seeds, scope []string, records []*model.JSRecord, pages []*model.PageRecord) error {
+	if err := utils.EnsureDirOf(path); err != nil {
+		return err
+	}
+	st, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	run := store.Run{StartedAt: start, FinishedAt: time.Now(), Seeds: seeds, Scope: scope}
+	id, err := st.SaveRun(run, records, pages, nil)
+	if err != nil {
+		return err
+	}
+	logify.Infof("Saved run %d (%d records, %d pages) to %s", id, len(records), len(pages), path)
 	return nil
 }
 
+// parseHeaders turns "Name: value" flags into a header map.
+func parseHeaders(list []string) map[string]string {
+	if len(list) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(list))
+	for _, h := range list {
+		if name, value, ok := strings.Cut(h, ":"); ok {
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
+
+// localeProfiles builds the locale profiles to crawl under: one per
+// --region, with --locale, --timezone and --geo overriding each of them,
+// or a single profile from those flags alone.
+func (r *Runner) localeProfiles() ([]engine.LocaleProfile, error) {
+	var geo *engine.Geolocation
+	if r.Cfg.Geolocation != "" {
+		g, err := engine.ParseGeolocation(r.Cfg.Geolocation)
+		if err != nil {
+			return nil, err
+		}
+		geo = g
+	}
+	custom := func(p engine.LocaleProfile) engine.LocaleProfile {
+		if r.Cfg.Locale != "" {
+			p.Locale = r.Cfg.Locale
+		}
+		if r.Cfg.Timezone != "" {
+			p.Timezone = r.Cfg.Timezone
+		}
+		if geo != nil {
+			p.Geo = geo
+		}
+		return p
+	}
+
+	var profiles []engine.LocaleProfile
+	seen := make(map[string]struct{}, len(r.Cfg.Regions))
+	for _, name := range r.Cfg.Regions {
+		p, ok := engine.LookupLocale(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown region: %s (use %s)", name, strings.Join(engine.LocaleNames(), "|"))
+		}
+		if _, dup := seen[p.Name]; dup {
+			continue
+		}
+		seen[p.Name] = struct{}{}
+		profiles = append(profiles, custom(p))
+	}
+	if len(profiles) == 0 && (r.Cfg.Locale != "" || r.Cfg.Timezone != "" || geo != nil) {
+		name := r.Cfg.Locale
+		if name == "" {
+			name = "custom"
+		}
+		profiles = append(profiles, custom(engine.LocaleProfile{Name: name}))
+	}
+	for _, p := range profiles {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+func logRestarts(eng *engine.Engine) {
+	if n := eng.BrowserRestarts(); n > 0 {
+		logify.Infof("Restarted browsers %d times (crash or recycling)", n)
+	}
+}
+
+// logChallenges summarizes pages that showed a bot challenge instead of
+// their content.
+func logChallenges(pages []*model.PageRecord, stealth bool) {
+	byName := map[string]int{}
+	for _, p := range pages {
+		if p.Challenge != "" {
+			byName[p.Challenge]++
+		}
+	}
+	if len(byName) == 0 {
+		return
+	}
+	names := make([]string, 0, len(byName))
+	total := 0
+	for name, n := range byName {
+		names = append(names, fmt.Sprintf("%s: %d", name, n))
+		total += n
+	}
+	sort.Strings(names)
+	hint := ""
+	if !stealth {
+		hint = " (try --stealth where automated testing is allowed)"
+	}
+	logify.Infof("Warning: bot challenge detected on %d pages (%s)%s", total, strings.Join(names, ", "), hint)
+}
+
+// logBlocked reports how many requests the resource blocking policy failed.
+func logBlocked(pages []*model.PageRecord) {
+	total := 0
+	for _, p := range pages {
+		total += p.Blocked
+	}
+	if total > 0 {
+		logify.Infof("Blocked %d requests by resource type or URL pattern", total)
+	}
+}
+
+func isStdout(path string) bool { return path == "-" || path == "" }
+
+// engineOptions maps the runtime config onto engine options for one crawl scope.
+func (r *Runner) engineOptions(allowed []string) engine.Options {
+	locales, _ := r.localeProfiles() // validated in Run
+	opt := engine.Options{
+		AllowedHosts:      allowed,
+		ChromePath:        r.Cfg.ChromePath,
+		RemoteURL:         r.Cfg.RemoteURL,
+		SeedConcurrency:   r.Cfg.SeedConcurrency,
+		Browsers:          r.Cfg.Browsers,
+		TabReuse:          r.Cfg.TabReuse,
+		Isolation:         r.Cfg.Isolation,
+		Cookies:           r.Cfg.Cookies,
+		Headers:           parseHeaders(r.Cfg.Headers),
+		RecycleAfterPages: r.Cfg.RecycleAfter,
+		RecycleRSSMB:      r.Cfg.RecycleRSSMB,
+		Headless:          r.Cfg.Headless,
+		UserAgent:         r.Cfg.UserAgent,
+		Stealth:           r.Cfg.Stealth,
+		Devices:           r.Cfg.Devices,
+		Locales:           locales,
+		BlockTypes:        r.Cfg.BlockTypes,
+		BlockURLs:         r.Cfg.BlockURLs,
+		AllowURLs:         r.Cfg.AllowURLs,
+		PageTimeout:       time.Duration(r.Cfg.PageTimeoutSec) * time.Second,
+		WaitAfterLoad:     time.Duration(r.Cfg.WaitSeconds) * time.Second,
+		WaitUntil:         r.Cfg.WaitUntil,
+		MaxDepth:          r.Cfg.MaxDepth,
+		MaxPages:          r.Cfg.MaxPages,
+		Concurrency:       r.Cfg.Concurrency,
+		SPARoutes:         r.Cfg.SPARoutes,
+		Initiators:        r.Cfg.Initiators,
+		Strategy:          r.Cfg.Strategy,
+		URLPolicy:         r.Cfg.URLPolicy,
+		Classifier:        engine.NewClassifier(r.Cfg.JSExtensions, r.Cfg.JSMIMETypes),
+		Sniff:             r.Cfg.Sniff,
+		HAR:               r.Cfg.HARPath != "",
+		HARBodies:         r.Cfg.HARBodies,
+		Explore:           r.Cfg.Explore,
+		ExploreMaxActions: r.Cfg.ExploreMaxActions,
+		FillForms:         r.Cfg.FillForms || r.Cfg.SubmitForms,
+		SubmitForms:       r.Cfg.SubmitForms,
+	}
+	if r.Cfg.ExploreSafe {
+		opt.ExploreDeny = append(opt.ExploreDeny, engine.DefaultExploreDenyList...)
+		opt.FormDeny = append(opt.FormDeny, engine.DefaultFormDenyList...)
+	}
+	opt.ExploreDeny = append(opt.ExploreDeny, r.Cfg.ExploreDeny...)
+	opt.FormDeny = append(opt.FormDeny, r.Cfg.ExploreDeny...)
+	return opt
+}
+
 // DetectChromePath remains in utils/ or could be in engine; omitted here for brevity.
@@ -0,0 +1,286 @@
+package runner
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyinnove/logify"
+
+	"github.com/cyinnove/jscout/pkg/model"
+	"github.com/cyinnove/jscout/utils"
+)
+
+// splitWriter streams JS records into one file per seed host (or, with
+// byJSHost, one file per JS host under a directory per seed host) as pages
+// complete, and keeps index.json in dir up to date.
+type splitWriter struct {
+	dir      string
+	format   string
+	ext      string
+	unique   bool
+	byJSHost bool
+	scope    []string // when set, only JS hosts in scope are written
+
+	mu     sync.Mutex
+	closed bool
+	files  map[string]*splitFile
+	index  map[string]*splitIndexSeed
+}
+
+type splitFile struct {
+	fh   *os.File
+	csv  *csv.Writer
+	enc  *json.Encoder
+	seen map[string]struct{}
+}
+
+type splitIndex struct {
+	Updated time.Time         `json:"updated"`
+	Format  string            `json:"format"`
+	Seeds   []*splitIndexSeed `json:"seeds"`
+}
+
+type splitIndexSeed struct {
+	Host          string            `json:"host"`
+	File          string            `json:"file,omitempty"`
+	Pages         int               `json:"pages"`
+	FailedPages   int               `json:"failed_pages"`
+	Records       int               `json:"records"`
+	UniqueScripts int               `json:"unique_scripts"`
+	JSHosts       []*splitIndexHost `json:"js_hosts,omitempty"`
+
+	scripts map[string]struct{}
+	hosts   map[string]*splitIndexHost
+}
+
+type splitIndexHost struct {
+	Host    string `json:"host"`
+	File    string `json:"file,omitempty"`
+	Records int    `json:"records"`
+}
+
+// splitExt maps the streamable formats to file extensions.
+func splitExt(format string) (string, bool) {
+	switch strings.ToLower(format) {
+	case "txt", "text":
+		return ".txt", true
+	case "jsonl", "ndjson":
+		return ".jsonl", true
+	case "csv":
+		return ".csv", true
+	}
+	return "", false
+}
+
+func newSplitWriter(dir, format string, unique, byJSHost bool, scope []string) (*splitWriter, error) {
+	ext, ok := splitExt(format)
+	if !ok {
+		return nil, fmt.Errorf("--output-dir supports txt|jsonl|csv, not %s", format)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &splitWriter{
+		dir:      dir,
+		format:   strings.ToLower(format),
+		ext:      ext,
+		unique:   unique,
+		byJSHost: byJSHost,
+		scope:    scope,
+		files:    map[string]*splitFile{},
+		index:    map[string]*splitIndexSeed{},
+	}, nil
+}
+
+// Page is an engine.Options.OnPage hook. Pages are attributed to the host
+// of the seed they were reached from (PageRecord.Seed).
+func (s *splitWriter) Page(p *model.PageRecord, js []*model.JSRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seed := hostOf(p.Seed)
+	if seed == "" {
+		seed = hostOf(p.URL)
+	}
+	entry := s.seed(seed)
+	entry.Pages++
+	if p.ErrorKind != "" {
+		entry.FailedPages++
+	}
+
+	for _, rec := range js {
+		ju, err := url.Parse(rec.JSURL)
+		if err != nil {
+			continue
+		}
+		if len(s.scope) > 0 && !utils.HostInScope(ju, s.scope) {
+			continue
+		}
+		jsHost := strings.ToLower(ju.Host)
+		rel := safeHostName(seed) + s.ext
+		if s.byJSHost {
+			rel = filepath.Join(safeHostName(seed), safeHostName(jsHost)+s.ext)
+		}
+		if err := s.write(rel, rec); err != nil {
+			logify.Warningf("Could not write %s: %v", filepath.Join(s.dir, rel), err)
+			continue
+		}
+		if !s.byJSHost {
+			entry.File = rel
+		}
+		entry.Records++
+		entry.scripts[rec.JSURL] = struct{}{}
+		h := entry.hosts[jsHost]
+		if h == nil {
+			h = &splitIndexHost{Host: jsHost}
+			if s.byJSHost {
+				h.File = rel
+			}
+			entry.hosts[jsHost] = h
+		}
+		h.Records++
+	}
+	for _, f := range s.files {
+		f.flush()
+	}
+	if err := s.writeIndex(); err != nil {
+		logify.Warningf("Could not write %s: %v", filepath.Join(s.dir, "index.json"), err)
+	}
+}
+
+func (s *splitWriter) seed(host string) *splitIndexSeed {
+	e := s.index[host]
+	if e == nil {
+		e = &splitIndexSeed{Host: host, scripts: map[string]struct{}{}, hosts: map[string]*splitIndexHost{}}
+		s.index[host] = e
+	}
+	return e
+}
+
+// write appends rec to the file at rel, opening it on first use.
+func (s *splitWriter) write(rel string, rec *model.JSRecord) error {
+	f := s.files[rel]
+	if f == nil {
+		path := filepath.Join(s.dir, rel)
+		if err := utils.EnsureDirOf(path); err != nil {
+			return err
+		}
+		fh, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		f = &splitFile{fh: fh, seen: map[string]struct{}{}}
+		switch s.format {
+		case "csv":
+			f.csv = csv.NewWriter(fh)
+			if err := f.csv.Write(utils.JSCSVHeader); err != nil {
+				fh.Close()
+				return err
+			}
+		case "jsonl", "ndjson":
+			f.enc = json.NewEncoder(fh)
+		}
+		s.files[rel] = f
+	}
+	switch {
+	case f.csv != nil:
+		return f.csv.Write(utils.JSCSVRow(rec))
+	case f.enc != nil:
+		return f.enc.Encode(rec)
+	default:
+		if s.unique {
+			if _, ok := f.seen[rec.JSURL]; ok {
+				return nil
+			}
+			f.seen[rec.JSURL] = struct{}{}
+		}
+		_, err := fmt.Fprintln(f.fh, rec.JSURL)
+		return err
+	}
+}
+
+func (f *splitFile) flush() {
+	if f.csv != nil {
+		f.csv.Flush()
+	}
+}
+
+// writeIndex replaces index.json through a temporary file so readers never
+// see it half written.
+func (s *splitWriter) writeIndex() error {
+	idx := splitIndex{Updated: time.Now().UTC(), Format: s.format}
+	for _, e := range s.index {
+		e.UniqueScripts = len(e.scripts)
+		e.JSHosts = e.JSHosts[:0]
+		for _, h := range e.hosts {
+			e.JSHosts = append(e.JSHosts, h)
+		}
+		sort.Slice(e.JSHosts, func(i, j int) bool { return e.JSHosts[i].Host < e.JSHosts[j].Host })
+		idx.Seeds = append(idx.Seeds, e)
+	}
+	sort.Slice(idx.Seeds, func(i, j int) bool { return idx.Seeds[i].Host < idx.Seeds[j].Host })
+
+	b, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.dir, ".index.json.tmp")
+	if err := os.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, "index.json"))
+}
+
+// Close flushes and closes every file and writes the final index. Calling
+// it again is a no-op.
+func (s *splitWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	var first error
+	for _, f := range s.files {
+		f.flush()
+		if f.csv != nil && f.csv.Error() != nil && first == nil {
+			first = f.csv.Error()
+		}
+		if err := f.fh.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	if err := s.writeIndex(); err != nil && first == nil {
+		first = err
+	}
+	return first
+}
+
+func hostOf(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		return strings.ToLower(u.Host)
+	}
+	return ""
+}
+
+// safeHostName turns a host (possibly with a port) into a file name.
+func safeHostName(host string) string {
+	if host == "" {
+		return "_unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, strings.ToLower(host))
+}
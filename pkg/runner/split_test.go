@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+func TestSplitWriterAttributesPagesToSeed(t *testing.T) {
+	dir := t.TempDir()
+	sw, err := newSplitWriter(dir, "txt", true, false, nil)
+	if err != nil {
+		t.Fatalf("new split writer: %v", err)
+	}
+	// b.com/page was linked from a.com but reached from the b.com seed; a
+	// page without a seed falls back to its own host
+	sw.Page(&model.PageRecord{URL: "https://a.com/", Seed: "https://a.com/"},
+		[]*model.JSRecord{{JSURL: "https://cdn.com/a.js"}})
+	sw.Page(&model.PageRecord{URL: "https://b.com/page", Parent: "https://a.com/", Seed: "https://b.com/"},
+		[]*model.JSRecord{{JSURL: "https://cdn.com/b.js"}})
+	sw.Page(&model.PageRecord{URL: "https://c.com/"},
+		[]*model.JSRecord{{JSURL: "https://cdn.com/c.js"}})
+	if err := sw.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	for file, want := range map[string]string{
+		"a.com.txt": "https://cdn.com/a.js\n",
+		"b.com.txt": "https://cdn.com/b.js\n",
+		"c.com.txt": "https://cdn.com/c.js\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		if string(got) != want {
+			t.Fatalf("%s = %q, want %q", file, got, want)
+		}
+	}
+}
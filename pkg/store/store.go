@@ -0,0 +1,455 @@
+// Package store keeps crawl results in a SQLite database so that runs
+// against the same targets accumulate, with first-seen and last-seen
+// timestamps on every page, script, endpoint and finding.
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+// timeLayout is fixed width UTC so timestamps compare correctly as text.
+const timeLayout = "2006-01-02T15:04:05Z"
+
+// Each entity table is keyed by URL and linked to the runs that saw it
+// through a <table>_runs table. The record column holds the latest full
+// record as JSON so new record fields need no schema change.
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at  TEXT NOT NULL,
+	finished_at TEXT NOT NULL,
+	seeds       TEXT NOT NULL,
+	scope       TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS pages (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	url          TEXT NOT NULL UNIQUE,
+	host         TEXT NOT NULL,
+	status       INTEGER NOT NULL,
+	title        TEXT NOT NULL,
+	error_kind   TEXT NOT NULL,
+	record       TEXT NOT NULL,
+	first_seen   TEXT NOT NULL,
+	last_seen    TEXT NOT NULL,
+	first_run_id INTEGER NOT NULL REFERENCES runs(id),
+	last_run_id  INTEGER NOT NULL REFERENCES runs(id)
+);
+CREATE TABLE IF NOT EXISTS scripts (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	js_url        TEXT NOT NULL UNIQUE,
+	host          TEXT NOT NULL,
+	source_page   TEXT NOT NULL,
+	status        INTEGER NOT NULL,
+	mime          TEXT NOT NULL,
+	resource_kind TEXT NOT NULL,
+	observation   TEXT NOT NULL,
+	record        TEXT NOT NULL,
+	first_seen    TEXT NOT NULL,
+	last_seen     TEXT NOT NULL,
+	first_run_id  INTEGER NOT NULL REFERENCES runs(id),
+	last_run_id   INTEGER NOT NULL REFERENCES runs(id)
+);
+CREATE TABLE IF NOT EXISTS endpoints (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	url          TEXT NOT NULL UNIQUE,
+	host         TEXT NOT NULL,
+	source_page  TEXT NOT NULL,
+	first_seen   TEXT NOT NULL,
+	last_seen    TEXT NOT NULL,
+	first_run_id INTEGER NOT NULL REFERENCES runs(id),
+	last_run_id  INTEGER NOT NULL REFERENCES runs(id)
+);
+CREATE TABLE IF NOT EXISTS findings (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind         TEXT NOT NULL,
+	severity     TEXT NOT NULL,
+	url          TEXT NOT NULL,
+	script_url   TEXT NOT NULL,
+	detail       TEXT NOT NULL,
+	first_seen   TEXT NOT NULL,
+	last_seen    TEXT NOT NULL,
+	first_run_id INTEGER NOT NULL REFERENCES runs(id),
+	last_run_id  INTEGER NOT NULL REFERENCES runs(id),
+	UNIQUE (kind, url, detail)
+);
+CREATE TABLE IF NOT EXISTS page_runs     (page_id INTEGER NOT NULL, run_id INTEGER NOT NULL, PRIMARY KEY (page_id, run_id));
+CREATE TABLE IF NOT EXISTS script_runs   (script_id INTEGER NOT NULL, run_id INTEGER NOT NULL, PRIMARY KEY (script_id, run_id));
+CREATE TABLE IF NOT EXISTS endpoint_runs (endpoint_id INTEGER NOT NULL, run_id INTEGER NOT NULL, PRIMARY KEY (endpoint_id, run_id));
+CREATE TABLE IF NOT EXISTS finding_runs  (finding_id INTEGER NOT NULL, run_id INTEGER NOT NULL, PRIMARY KEY (finding_id, run_id));
+CREATE INDEX IF NOT EXISTS pages_host     ON pages(host);
+CREATE INDEX IF NOT EXISTS scripts_host   ON scripts(host);
+CREATE INDEX IF NOT EXISTS scripts_first  ON scripts(first_seen);
+CREATE INDEX IF NOT EXISTS endpoints_host ON endpoints(host);
+`
+
+// Store is an open crawl database.
+type Store struct {
+	db *sql.DB
+}
+
+// Run is one crawl saved in the database.
+type Run struct {
+	ID         int64     `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Seeds      []string  `json:"seeds"`
+	Scope      []string  `json:"scope"`
+}
+
+// Endpoint is an in-scope URL discovered on a crawled page: a link or SPA
+// route (model.PageRecord.Links) or a form target (model.PageRecord.Forms).
+type Endpoint struct {
+	URL        string `json:"url"`
+	SourcePage string `json:"source_page"`
+	FirstSeen  string `json:"first_seen"`
+	LastSeen   string `json:"last_seen"`
+}
+
+// Finding is an analyzer result attached to a URL or script. Findings are
+// keyed by kind, URL and detail; the crawler records none yet, so the
+// table stays empty until an analyzer fills it.
+type Finding struct {
+	Kind      string `json:"kind"`
+	Severity  string `json:"severity"`
+	URL       string `json:"url"`
+	ScriptURL string `json:"script_url"`
+	Detail    string `json:"detail"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+}
+
+// Open opens (creating if needed) the database at path.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection avoids SQLITE_BUSY between our own statements
+	db.SetMaxOpenConns(1)
+	for _, pragma := range []string{"PRAGMA journal_mode=WAL", "PRAGMA busy_timeout=5000", "PRAGMA foreign_keys=ON"} {
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: %w", pragma, err)
+		}
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error { return s.db.Close() }
+
+// SaveRun stores one crawl and returns its run id. Pages, scripts,
+// endpoints and findings already in the database keep their first-seen
+// time and get their last-seen time and latest record updated. The
+// FirstSeen and LastSeen fields of findings are ignored.
+func (s *Store) SaveRun(run Run, records []*model.JSRecord, pages []*model.PageRecord, findings []Finding) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	seeds, _ := json.Marshal(nonNil(run.Seeds))
+	scope, _ := json.Marshal(nonNil(run.Scope))
+	res, err := tx.Exec(`INSERT INTO runs (started_at, finished_at, seeds, scope) VALUES (?, ?, ?, ?)`,
+		formatTime(run.StartedAt), formatTime(run.FinishedAt), string(seeds), string(scope))
+	if err != nil {
+		return 0, fmt.Errorf("insert run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	now := formatTime(run.FinishedAt)
+
+	for _, p := range pages {
+		rec, _ := json.Marshal(p)
+		var id int64
+		err := tx.QueryRow(`
+			INSERT INTO pages (url, host, status, title, error_kind, record, first_seen, last_seen, first_run_id, last_run_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(url) DO UPDATE SET
+				status = excluded.status, title = excluded.title, error_kind = excluded.error_kind,
+				record = excluded.record, last_seen = excluded.last_seen, last_run_id = excluded.last_run_id
+			RETURNING id`,
+			p.URL, hostOf(p.URL), p.Status, p.Title, p.ErrorKind, string(rec), now, now, runID, runID,
+		).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("save page %s: %w", p.URL, err)
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO page_runs (page_id, run_id) VALUES (?, ?)`, id, runID); err != nil {
+			return 0, err
+		}
+
+		endpoints := make([]string, 0, len(p.Links)+len(p.Forms))
+		endpoints = append(append(endpoints, p.Links...), p.Forms...)
+		for _, l := range endpoints {
+			var eid int64
+			err := tx.QueryRow(`
+				INSERT INTO endpoints (url, host, source_page, first_seen, last_seen, first_run_id, last_run_id)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT(url) DO UPDATE SET last_seen = excluded.last_seen, last_run_id = excluded.last_run_id
+				RETURNING id`,
+				l, hostOf(l), p.URL, now, now, runID, runID,
+			).Scan(&eid)
+			if err != nil {
+				return 0, fmt.Errorf("save endpoint %s: %w", l, err)
+			}
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO endpoint_runs (endpoint_id, run_id) VALUES (?, ?)`, eid, runID); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	for _, r := range records {
+		rec, _ := json.Marshal(r)
+		var id int64
+		err := tx.QueryRow(`
+			INSERT INTO scripts (js_url, host, source_page, status, mime, resource_kind, observation, record, first_seen, last_seen, first_run_id, last_run_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(js_url) DO UPDATE SET
+				source_page = excluded.source_page, status = excluded.status, mime = excluded.mime,
+				resource_kind = excluded.resource_kind, observation = excluded.observation, record = excluded.record,
+				last_seen = excluded.last_seen, last_run_id = excluded.last_run_id
+			RETURNING id`,
+			r.JSURL, hostOf(r.JSURL), r.SourcePage, r.Status, r.MIME, r.ResourceKind, r.Observation, string(rec),
+			now, now, runID, runID,
+		).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("save script %s: %w", r.JSURL, err)
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO script_runs (script_id, run_id) VALUES (?, ?)`, id, runID); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, fd := range findings {
+		var id int64
+		err := tx.QueryRow(`
+			INSERT INTO findings (kind, severity, url, script_url, detail, first_seen, last_seen, first_run_id, last_run_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(kind, url, detail) DO UPDATE SET
+				severity = excluded.severity, script_url = excluded.script_url,
+				last_seen = excluded.last_seen, last_run_id = excluded.last_run_id
+			RETURNING id`,
+			fd.Kind, fd.Severity, fd.URL, fd.ScriptURL, fd.Detail, now, now, runID, runID,
+		).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("save finding %s %s: %w", fd.Kind, fd.URL, err)
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO finding_runs (finding_id, run_id) VALUES (?, ?)`, id, runID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return runID, nil
+}
+
+// Filter narrows queries. Zero values match everything.
+type Filter struct {
+	Hosts          []string  // host suffixes, as in the crawl scope
+	RunID          int64     // only rows seen in this run
+	FirstSeenSince time.Time // first seen at or after
+	FirstSeenUntil time.Time // first seen before
+	Kinds          []string  // script resource kinds, or finding kinds for findings
+}
+
+// where builds the WHERE clause for an entity table aliased as t whose run
+// link table is <entity>_runs. kindColumn names the column Kinds filters
+// on; tables without one pass "".
+func (f Filter) where(entity, kindColumn string) (string, []any) {
+	var conds []string
+	var args []any
+	if len(f.Hosts) > 0 {
+		var hc []string
+		for _, h := range f.Hosts {
+			h = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(h), "."))
+			if h == "" {
+				continue
+			}
+			hc = append(hc, "t.host = ? OR t.host LIKE ?")
+			args = append(args, h, "%."+h)
+		}
+		if len(hc) > 0 {
+			conds = append(conds, "("+strings.Join(hc, " OR ")+")")
+		}
+	}
+	if f.RunID > 0 {
+		conds = append(conds, fmt.Sprintf("EXISTS (SELECT 1 FROM %[1]s_runs r WHERE r.%[1]s_id = t.id AND r.run_id = ?)", entity))
+		args = append(args, f.RunID)
+	}
+	if !f.FirstSeenSince.IsZero() {
+		conds = append(conds, "t.first_seen >= ?")
+		args = append(args, formatTime(f.FirstSeenSince))
+	}
+	if !f.FirstSeenUntil.IsZero() {
+		conds = append(conds, "t.first_seen < ?")
+		args = append(args, formatTime(f.FirstSeenUntil))
+	}
+	if kindColumn != "" && len(f.Kinds) > 0 {
+		conds = append(conds, "t."+kindColumn+" IN (?"+strings.Repeat(", ?", len(f.Kinds)-1)+")")
+		for _, k := range f.Kinds {
+			args = append(args, strings.ToLower(strings.TrimSpace(k)))
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// Scripts returns the latest record of every script matching f, oldest first.
+func (s *Store) Scripts(f Filter) ([]*model.JSRecord, error) {
+	where, args := f.where("script", "resource_kind")
+	rows, err := s.db.Query(`SELECT t.record FROM scripts t`+where+` ORDER BY t.first_seen, t.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []*model.JSRecord{}
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		rec := &model.JSRecord{}
+		if err := json.Unmarshal([]byte(raw), rec); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// Pages returns the latest record of every page matching f, oldest first.
+func (s *Store) Pages(f Filter) ([]*model.PageRecord, error) {
+	where, args := f.where("page", "")
+	rows, err := s.db.Query(`SELECT t.record FROM pages t`+where+` ORDER BY t.first_seen, t.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []*model.PageRecord{}
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		rec := &model.PageRecord{}
+		if err := json.Unmarshal([]byte(raw), rec); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// Endpoints returns the endpoints matching f, oldest first.
+func (s *Store) Endpoints(f Filter) ([]Endpoint, error) {
+	where, args := f.where("endpoint", "")
+	rows, err := s.db.Query(`SELECT t.url, t.source_page, t.first_seen, t.last_seen FROM endpoints t`+where+` ORDER BY t.first_seen, t.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Endpoint{}
+	for rows.Next() {
+		var e Endpoint
+		if err := rows.Scan(&e.URL, &e.SourcePage, &e.FirstSeen, &e.LastSeen); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Findings returns the findings matching f, oldest first. The findings
+// table has no host column, so the host filter does not apply.
+func (s *Store) Findings(f Filter) ([]Finding, error) {
+	f.Hosts = nil
+	where, args := f.where("finding", "kind")
+	rows, err := s.db.Query(`SELECT t.kind, t.severity, t.url, t.script_url, t.detail, t.first_seen, t.last_seen FROM findings t`+where+` ORDER BY t.first_seen, t.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Finding{}
+	for rows.Next() {
+		var fd Finding
+		if err := rows.Scan(&fd.Kind, &fd.Severity, &fd.URL, &fd.ScriptURL, &fd.Detail, &fd.FirstSeen, &fd.LastSeen); err != nil {
+			return nil, err
+		}
+		out = append(out, fd)
+	}
+	return out, rows.Err()
+}
+
+// Runs lists saved runs, oldest first.
+func (s *Store) Runs() ([]Run, error) {
+	rows, err := s.db.Query(`SELECT id, started_at, finished_at, seeds, scope FROM runs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Run
+	for rows.Next() {
+		var r Run
+		var started, finished, seeds, scope string
+		if err := rows.Scan(&r.ID, &started, &finished, &seeds, &scope); err != nil {
+			return nil, err
+		}
+		r.StartedAt, _ = time.Parse(timeLayout, started)
+		r.FinishedAt, _ = time.Parse(timeLayout, finished)
+		_ = json.Unmarshal([]byte(seeds), &r.Seeds)
+		_ = json.Unmarshal([]byte(scope), &r.Scope)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// LatestRun returns the id of the most recent run, or 0 for an empty database.
+func (s *Store) LatestRun() (int64, error) {
+	var id sql.NullInt64
+	if err := s.db.QueryRow(`SELECT MAX(id) FROM runs`).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id.Int64, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format(timeLayout)
+}
+
+func hostOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+func TestSaveRunEndpoints(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "jscout.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer st.Close()
+
+	pages := []*model.PageRecord{{
+		URL:   "https://a.com/",
+		Links: []string{"https://a.com/about", "https://a.com/#/settings"},
+		Forms: []string{"https://a.com/search", "https://a.com/about"},
+	}}
+	records := []*model.JSRecord{{JSURL: "https://a.com/app.js", SourcePage: "https://a.com/"}}
+	first, err := st.SaveRun(Run{StartedAt: time.Now(), FinishedAt: time.Now()}, records, pages, nil)
+	if err != nil {
+		t.Fatalf("save run: %v", err)
+	}
+
+	eps, err := st.Endpoints(Filter{})
+	if err != nil {
+		t.Fatalf("endpoints: %v", err)
+	}
+	want := []string{"https://a.com/about", "https://a.com/#/settings", "https://a.com/search"}
+	if len(eps) != len(want) {
+		t.Fatalf("expected %d endpoints, got %+v", len(want), eps)
+	}
+	for i, e := range eps {
+		if e.URL != want[i] || e.SourcePage != "https://a.com/" {
+			t.Fatalf("endpoint %d = %+v, want %s from https://a.com/", i, e, want[i])
+		}
+	}
+
+	// A second run only adds what is new; the run filter sees both
+	pages[0].Forms = []string{"https://a.com/login"}
+	second, err := st.SaveRun(Run{StartedAt: time.Now(), FinishedAt: time.Now()}, records, pages, nil)
+	if err != nil {
+		t.Fatalf("save second run: %v", err)
+	}
+	if eps, err = st.Endpoints(Filter{RunID: second}); err != nil || len(eps) != 3 {
+		t.Fatalf("expected 3 endpoints in run %d, got %+v (%v)", second, eps, err)
+	}
+	if eps, err = st.Endpoints(Filter{RunID: first}); err != nil || len(eps) != 3 {
+		t.Fatalf("expected 3 endpoints in run %d, got %+v (%v)", first, eps, err)
+	}
+	if eps, err = st.Endpoints(Filter{}); err != nil || len(eps) != 4 {
+		t.Fatalf("expected 4 endpoints overall, got %+v (%v)", eps, err)
+	}
+}
+
+func TestSaveRunFindings(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "jscout.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer st.Close()
+
+	// A run without findings leaves the table empty
+	if _, err := st.SaveRun(Run{StartedAt: time.Now(), FinishedAt: time.Now()}, nil, nil, nil); err != nil {
+		t.Fatalf("save empty run: %v", err)
+	}
+	if fds, err := st.Findings(Filter{}); err != nil || len(fds) != 0 {
+		t.Fatalf("expected no findings, got %+v (%v)", fds, err)
+	}
+
+	findings := []Finding{
+		{Kind: "secret", Severity: "high", URL: "https://a.com/", ScriptURL: "https://a.com/app.js", Detail: "aws key"},
+		{Kind: "sourcemap", Severity: "info", URL: "https://a.com/", ScriptURL: "https://a.com/app.js", Detail: "app.js.map"},
+	}
+	first, err := st.SaveRun(Run{StartedAt: time.Now(), FinishedAt: time.Now()}, nil, nil, findings)
+	if err != nil {
+		t.Fatalf("save run: %v", err)
+	}
+	second, err := st.SaveRun(Run{StartedAt: time.Now(), FinishedAt: time.Now()}, nil, nil, findings[:1])
+	if err != nil {
+		t.Fatalf("save second run: %v", err)
+	}
+
+	if fds, err := st.Findings(Filter{}); err != nil || len(fds) != 2 {
+		t.Fatalf("expected 2 findings overall, got %+v (%v)", fds, err)
+	}
+	if fds, err := st.Findings(Filter{RunID: first}); err != nil || len(fds) != 2 {
+		t.Fatalf("expected 2 findings in run %d, got %+v (%v)", first, fds, err)
+	}
+	fds, err := st.Findings(Filter{RunID: second})
+	if err != nil || len(fds) != 1 || fds[0].Detail != "aws key" {
+		t.Fatalf("expected the aws key finding in run %d, got %+v (%v)", second, fds, err)
+	}
+	if fds, err = st.Findings(Filter{Kinds: []string{"sourcemap"}}); err != nil || len(fds) != 1 || fds[0].Kind != "sourcemap" {
+		t.Fatalf("expected one sourcemap finding, got %+v (%v)", fds, err)
+	}
+}
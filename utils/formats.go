@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+// OutputData is what a format writer renders: the records of a crawl plus
+// the context some formats need.
+type OutputData struct {
+	Records      []*model.JSRecord
+	Pages        []*model.PageRecord
+	Seeds        []string
+	Scope        []string
+	Unique       bool   // de-duplicate by JSURL (txt)
+	TemplatePath string // text/template file for the template format
+}
+
+// FormatWriter renders OutputData in one format.
+type FormatWriter func(w io.Writer, data OutputData) error
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]FormatWriter{}
+)
+
+func init() {
+	for _, name := range []string{"txt", "text", "jsonl", "ndjson", "csv"} {
+		RegisterFormat(name, func(w io.Writer, d OutputData) error {
+			return writeRecords(w, name, d.Unique, d.Records)
+		})
+	}
+	RegisterFormat("html", func(w io.Writer, d OutputData) error {
+		title := ""
+		if len(d.Seeds) > 0 {
+			title = "jscout report: " + strings.Join(d.Seeds, ", ")
+		}
+		return WriteHTMLReport(w, Report{Title: title, Scope: d.Scope, Records: d.Records, Pages: d.Pages})
+	})
+	RegisterFormat("template", writeTemplate)
+}
+
+// RegisterFormat adds or replaces an output format. Names are case-insensitive.
+func RegisterFormat(name string, fn FormatWriter) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[strings.ToLower(name)] = fn
+}
+
+// LookupFormat returns the writer registered under name.
+func LookupFormat(name string) (FormatWriter, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	fn, ok := formats[strings.ToLower(name)]
+	return fn, ok
+}
+
+// Formats lists the registered format names, sorted.
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	out := make([]string, 0, len(formats))
+	for name := range formats {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Write renders data in the named format.
+func Write(w io.Writer, format string, data OutputData) error {
+	fn, ok := LookupFormat(format)
+	if !ok {
+		if strings.EqualFold(format, "sqlite") {
+			return fmt.Errorf("sqlite output needs a database path, not a stream")
+		}
+		return fmt.Errorf("unknown format: %s", format)
+	}
+	return fn(w, data)
+}
+
+// templateFuncs are available to template-format files.
+var templateFuncs = template.FuncMap{
+	"host": func(raw string) string {
+		if u, err := url.Parse(raw); err == nil {
+			return u.Host
+		}
+		return ""
+	},
+	"origin": func(raw string) string {
+		if u, err := url.Parse(raw); err == nil && u.Host != "" {
+			return u.Scheme + "://" + u.Host
+		}
+		return ""
+	},
+	"path": func(raw string) string {
+		if u, err := url.Parse(raw); err == nil {
+			return u.Path
+		}
+		return ""
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"join":  strings.Join,
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ParseTemplate reads a template-format file, so callers can validate it
+// before crawling.
+func ParseTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return nil, fmt.Errorf("template format needs a template file (--template)")
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(src))
+}
+
+// writeTemplate executes the template once per record (a *model.JSRecord),
+// ending each rendering with a newline unless it is empty or has one.
+func writeTemplate(w io.Writer, d OutputData) error {
+	tmpl, err := ParseTemplate(d.TemplatePath)
+	if err != nil {
+		return err
+	}
+	seen := map[string]struct{}{}
+	bw := bufio.NewWriter(w)
+	var sb strings.Builder
+	for _, r := range d.Records {
+		if d.Unique {
+			if _, ok := seen[r.JSURL]; ok {
+				continue
+			}
+			seen[r.JSURL] = struct{}{}
+		}
+		sb.Reset()
+		if err := tmpl.Execute(&sb, r); err != nil {
+			return err
+		}
+		out := sb.String()
+		if out != "" && !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		if _, err := bw.WriteString(out); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
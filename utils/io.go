@@ -2,6 +2,7 @@ package utils
 
 import (
     "bufio"
+    "io"
     "os"
     "path/filepath"
     "strings"
@@ -33,3 +34,23 @@ func EnsureDirOf(path string) error {
     return os.MkdirAll(dir, 0755)
 }
 
+
+// WriteTo runs fn against STDOUT for "-" (or empty) and against a newly
+// created file otherwise, creating parent directories as needed.
+func WriteTo(path string, fn func(w io.Writer) error) error {
+    if path == "-" || path == "" {
+        return fn(os.Stdout)
+    }
+    if err := EnsureDirOf(path); err != nil {
+        return err
+    }
+    fh, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    if err := fn(fh); err != nil {
+        fh.Close()
+        return err
+    }
+    return fh.Close()
+}
@@ -6,11 +6,19 @@ import (
     "encoding/json"
     "fmt"
     "io"
+    "sort"
+    "strings"
 
     "github.com/cyinnove/jscout/pkg/model"
 )
 
+// WriteOutput writes JS records in any registered format (see RegisterFormat).
 func WriteOutput(w io.Writer, format string, unique bool, records []*model.JSRecord) error {
+    return Write(w, format, OutputData{Records: records, Unique: unique})
+}
+
+// writeRecords implements the built-in txt, jsonl and csv formats.
+func writeRecords(w io.Writer, format string, unique bool, records []*model.JSRecord) error {
     switch lower(format) {
     case "txt", "text":
         seen := map[string]struct{}{}
@@ -37,11 +45,102 @@ func WriteOutput(w io.Writer, format string, unique bool, records []*model.JSRec
         return nil
     case "csv":
         cw := csv.NewWriter(w)
-        if err := cw.Write([]string{"js_url", "source_page", "status", "mime", "from_cache"}); err != nil {
+        if err := cw.Write(JSCSVHeader); err != nil {
             return err
         }
         for _, r := range records {
-            row := []string{r.JSURL, r.SourcePage, fmt.Sprintf("%d", r.Status), r.MIME, fmt.Sprintf("%v", r.FromCache)}
+            if err := cw.Write(JSCSVRow(r)); err != nil {
+                return err
+            }
+        }
+        cw.Flush()
+        return cw.Error()
+    default:
+        return fmt.Errorf("unknown format: %s", format)
+    }
+}
+
+// JSCSVHeader is the fixed column order of the csv format. New columns are
+// only ever appended so existing consumers keep working.
+var JSCSVHeader = []string{
+    "js_url", "source_page", "status", "mime", "from_cache",
+    "observation", "trigger",
+    "initiator_type", "initiator_url", "initiator_function", "initiator_line", "initiator_column",
+    "encoded_size", "decoded_size", "ttfb_ms", "duration_ms",
+    "module", "async", "defer", "integrity", "crossorigin",
+    "server", "last_modified", "etag", "cache_control", "cdn_headers",
+    "raw_url", "resource_kind", "profile", "locale",
+}
+
+// headerColumns are the headers given a dedicated csv column; the remaining
+// captured headers are folded into cdn_headers.
+var headerColumns = map[string]struct{}{"server": {}, "last-modified": {}, "etag": {}, "cache-control": {}}
+
+// JSCSVRow is the csv row of a record, in JSCSVHeader order.
+func JSCSVRow(r *model.JSRecord) []string {
+    var cdn []string
+    for k, v := range r.Headers {
+        if _, ok := headerColumns[k]; !ok {
+            cdn = append(cdn, k+"="+v)
+        }
+    }
+    sort.Strings(cdn)
+    return []string{
+        r.JSURL, r.SourcePage, fmt.Sprintf("%d", r.Status), r.MIME, fmt.Sprintf("%v", r.FromCache),
+        r.Observation, r.Trigger,
+        r.InitiatorType, r.InitiatorURL, r.InitiatorFunction, fmt.Sprintf("%d", r.InitiatorLine), fmt.Sprintf("%d", r.InitiatorColumn),
+        fmt.Sprintf("%d", r.EncodedSize), fmt.Sprintf("%d", r.DecodedSize), fmt.Sprintf("%.1f", r.TTFBMS), fmt.Sprintf("%.1f", r.DurationMS),
+        fmt.Sprintf("%v", r.Module), fmt.Sprintf("%v", r.Async), fmt.Sprintf("%v", r.Defer), r.Integrity, r.CrossOrigin,
+        r.Headers["server"], r.Headers["last-modified"], r.Headers["etag"], r.Headers["cache-control"], strings.Join(cdn, "; "),
+        r.RawURL, r.ResourceKind, r.Profile, r.Locale,
+    }
+}
+
+// WritePages writes page records in the same formats as WriteOutput.
+// The txt format prints one "url [status] [title]" line per page, with the
+// error kind in place of the title for failed pages and "challenge:<name>"
+// for pages that showed a bot challenge.
+func WritePages(w io.Writer, format string, pages []*model.PageRecord) error {
+    switch lower(format) {
+    case "txt", "text":
+        bw := bufio.NewWriter(w)
+        for _, p := range pages {
+            detail := p.Title
+            if p.Challenge != "" {
+                detail = "challenge:" + p.Challenge
+            }
+            if p.ErrorKind != "" {
+                detail = p.ErrorKind
+                if p.ErrorCode != "" {
+                    detail += " " + p.ErrorCode
+                }
+            }
+            if _, err := fmt.Fprintf(bw, "%s [%d] [%s]\n", p.URL, p.Status, detail); err != nil {
+                return err
+            }
+        }
+        return bw.Flush()
+    case "jsonl", "ndjson":
+        enc := json.NewEncoder(w)
+        for _, p := range pages {
+            if err := enc.Encode(p); err != nil {
+                return err
+            }
+        }
+        return nil
+    case "csv":
+        cw := csv.NewWriter(w)
+        header := []string{"url", "final_url", "status", "title", "depth", "parent", "redirects", "js_count", "load_ms", "idle_ms", "total_ms", "error_kind", "error_code", "error", "challenge", "profile", "locale", "blocked"}
+        if err := cw.Write(header); err != nil {
+            return err
+        }
+        for _, p := range pages {
+            row := []string{
+                p.URL, p.FinalURL, fmt.Sprintf("%d", p.Status), p.Title, fmt.Sprintf("%d", p.Depth), p.Parent,
+                strings.Join(p.Redirects, " "), fmt.Sprintf("%d", p.JSCount),
+                fmt.Sprintf("%d", p.LoadMS), fmt.Sprintf("%d", p.IdleMS), fmt.Sprintf("%d", p.TotalMS),
+                p.ErrorKind, p.ErrorCode, p.Error, p.Challenge, p.Profile, p.Locale, fmt.Sprintf("%d", p.Blocked),
+            }
             if err := cw.Write(row); err != nil {
                 return err
             }
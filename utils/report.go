@@ -0,0 +1,298 @@
+package utils
+
+import (
+	"html/template"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cyinnove/jscout/pkg/model"
+)
+
+// Report is everything WriteHTMLReport renders. Only Records is required.
+type Report struct {
+	Title     string
+	Scope     []string // first-party host suffixes; derived from source pages when empty
+	Records   []*model.JSRecord
+	Pages     []*model.PageRecord
+	Findings  []ReportFinding
+	Generated time.Time
+}
+
+// ReportFinding is an analyzer result listed in the report.
+type ReportFinding struct {
+	Kind     string
+	Severity string
+	URL      string
+	Detail   string
+}
+
+type reportScript struct {
+	*model.JSRecord
+	Host       string
+	ThirdParty bool
+	SourceMap  string
+}
+
+type reportNode struct {
+	*model.PageRecord
+	Children []*reportNode
+}
+
+type reportCount struct {
+	Name  string
+	Count int
+}
+
+type reportData struct {
+	Title     string
+	Generated string
+	Stats     []reportCount
+	Kinds     []reportCount
+	Hosts     []reportCount
+	Scripts   []reportScript
+	Trees     []*reportNode
+	Findings  []ReportFinding
+}
+
+// WriteHTMLReport renders a single self-contained HTML file (inline CSS and
+// JS, no external resources) with summary statistics, the page tree of each
+// seed and a filterable script table.
+func WriteHTMLReport(w io.Writer, rep Report) error {
+	data := reportData{Title: rep.Title, Findings: rep.Findings}
+	if data.Title == "" {
+		data.Title = "jscout report"
+	}
+	gen := rep.Generated
+	if gen.IsZero() {
+		gen = time.Now()
+	}
+	data.Generated = gen.UTC().Format(time.RFC1123)
+
+	unique := map[string]struct{}{}
+	kinds := map[string]int{}
+	hosts := map[string]int{}
+	third, referenced := 0, 0
+	for _, r := range rep.Records {
+		s := reportScript{JSRecord: r}
+		if u, err := url.Parse(r.JSURL); err == nil {
+			s.Host = strings.ToLower(u.Hostname())
+			s.ThirdParty = !firstParty(u, r.SourcePage, rep.Scope)
+			if sm := r.Headers["sourcemap"]; sm != "" {
+				s.SourceMap = resolveRef(u, sm)
+			} else if sm := r.Headers["x-sourcemap"]; sm != "" {
+				s.SourceMap = resolveRef(u, sm)
+			}
+		}
+		if s.ThirdParty {
+			third++
+		}
+		if r.Observation == model.ReferencedOnly {
+			referenced++
+		}
+		kind := r.ResourceKind
+		if kind == "" {
+			kind = "javascript"
+		}
+		kinds[kind]++
+		hosts[s.Host]++
+		unique[r.JSURL] = struct{}{}
+		data.Scripts = append(data.Scripts, s)
+	}
+
+	failed, challenged := 0, 0
+	for _, p := range rep.Pages {
+		if p.ErrorKind != "" {
+			failed++
+		}
+		if p.Challenge != "" {
+			challenged++
+		}
+	}
+	data.Stats = []reportCount{
+		{"Pages visited", len(rep.Pages)},
+		{"Pages failed", failed},
+		{"Bot challenges", challenged},
+		{"Script records", len(rep.Records)},
+		{"Unique scripts", len(unique)},
+		{"Third-party", third},
+		{"Referenced only", referenced},
+		{"Script hosts", len(hosts)},
+		{"Findings", len(rep.Findings)},
+	}
+	data.Kinds = sortedCounts(kinds)
+	data.Hosts = sortedCounts(hosts)
+	data.Trees = pageTrees(rep.Pages)
+
+	return reportTemplate.Execute(w, data)
+}
+
+// firstParty reports whether a script host is within scope or, without a
+// scope, shares the base domain of the page that loaded it.
+func firstParty(u *url.URL, sourcePage string, scope []string) bool {
+	if len(scope) > 0 {
+		return HostInScope(u, scope)
+	}
+	su, err := url.Parse(sourcePage)
+	if err != nil || su.Host == "" {
+		return false
+	}
+	return HostInScope(u, []string{ExtractBaseDomain(su.Hostname())})
+}
+
+func resolveRef(base *url.URL, ref string) string {
+	r, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(r).String()
+}
+
+func sortedCounts(m map[string]int) []reportCount {
+	out := make([]reportCount, 0, len(m))
+	for k, v := range m {
+		out = append(out, reportCount{k, v})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// pageTrees nests pages under their parent; seeds and pages whose parent
+// was not recorded become roots.
+func pageTrees(pages []*model.PageRecord) []*reportNode {
+	nodes := make(map[string]*reportNode, len(pages))
+	for _, p := range pages {
+		if _, ok := nodes[p.URL]; !ok {
+			nodes[p.URL] = &reportNode{PageRecord: p}
+		}
+	}
+	var roots []*reportNode
+	for _, p := range pages {
+		n := nodes[p.URL]
+		if n.PageRecord != p {
+			continue // duplicate visit of the same URL
+		}
+		if parent, ok := nodes[p.Parent]; ok && p.Parent != "" && parent != n {
+			parent.Children = append(parent.Children, n)
+			continue
+		}
+		roots = append(roots, n)
+	}
+	return roots
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`{{define "node"}}<li><a href="{{.URL}}">{{.URL}}</a> <span class="muted">[{{.Status}}]</span> {{if .ErrorKind}}<span class="err">{{.ErrorKind}} {{.ErrorCode}}</span>{{else}}{{.Title}}{{end}} <span class="muted">{{.JSCount}} JS</span>{{if .Children}}<ul class="tree">{{range .Children}}{{template "node" .}}{{end}}</ul>{{end}}</li>{{end}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font: 14px/1.45 -apple-system, "Segoe UI", Roboto, sans-serif; margin: 0; color: #1d2330; background: #f5f6f8; }
+header { background: #1d2330; color: #fff; padding: 16px 24px; }
+header h1 { margin: 0; font-size: 20px; }
+header p { margin: 4px 0 0; color: #aab2c0; font-size: 12px; }
+main { padding: 16px 24px; }
+section { background: #fff; border-radius: 6px; padding: 12px 16px; margin-bottom: 16px; box-shadow: 0 1px 2px rgba(0,0,0,.08); }
+h2 { font-size: 16px; margin: 0 0 10px; }
+.stats { display: flex; flex-wrap: wrap; gap: 10px; }
+.stat { background: #f0f2f6; border-radius: 4px; padding: 8px 12px; min-width: 110px; }
+.stat b { display: block; font-size: 20px; }
+.chips span { display: inline-block; background: #eef1f6; border-radius: 10px; padding: 1px 8px; margin: 2px; font-size: 12px; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+th, td { text-align: left; padding: 4px 6px; border-bottom: 1px solid #eceef2; vertical-align: top; }
+th { background: #f0f2f6; position: sticky; top: 0; }
+td.url { word-break: break-all; }
+.third { color: #a0522d; }
+.err { color: #c0392b; }
+.muted { color: #7a8394; }
+.filters { display: flex; flex-wrap: wrap; gap: 8px; margin-bottom: 10px; }
+.filters input, .filters select { padding: 4px 6px; font-size: 13px; }
+ul.tree { list-style: none; padding-left: 18px; margin: 2px 0; }
+ul.tree li { margin: 2px 0; word-break: break-all; }
+a { color: #2a62c9; text-decoration: none; }
+a:hover { text-decoration: underline; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p>Generated {{.Generated}} by jscout</p>
+</header>
+<main>
+<section>
+<h2>Summary</h2>
+<div class="stats">{{range .Stats}}<div class="stat"><b>{{.Count}}</b>{{.Name}}</div>{{end}}</div>
+{{if .Kinds}}<p class="chips">Kinds: {{range .Kinds}}<span>{{.Name}} {{.Count}}</span>{{end}}</p>{{end}}
+</section>
+{{if .Trees}}
+<section>
+<h2>Pages</h2>
+<ul class="tree">{{range .Trees}}{{template "node" .}}{{end}}</ul>
+</section>
+{{end}}
+{{if .Findings}}
+<section>
+<h2>Findings</h2>
+<table>
+<thead><tr><th>Severity</th><th>Kind</th><th>URL</th><th>Detail</th></tr></thead>
+<tbody>{{range .Findings}}<tr><td>{{.Severity}}</td><td>{{.Kind}}</td><td class="url">{{.URL}}</td><td>{{.Detail}}</td></tr>{{end}}</tbody>
+</table>
+</section>
+{{end}}
+<section>
+<h2>Scripts</h2>
+<div class="filters">
+<input id="q" type="search" placeholder="Filter URL or page">
+<select id="party"><option value="">All parties</option><option value="first">First-party</option><option value="third">Third-party</option></select>
+<select id="host"><option value="">All hosts</option>{{range .Hosts}}<option value="{{.Name}}">{{.Name}} ({{.Count}})</option>{{end}}</select>
+<select id="kind"><option value="">All kinds</option>{{range .Kinds}}<option value="{{.Name}}">{{.Name}} ({{.Count}})</option>{{end}}</select>
+<span id="shown" class="muted"></span>
+</div>
+<table id="scripts">
+<thead><tr><th>Script</th><th>Kind</th><th>Status</th><th>Source page</th><th>Initiator</th><th>Size</th><th>Source map</th></tr></thead>
+<tbody>
+{{range .Scripts}}<tr data-party="{{if .ThirdParty}}third{{else}}first{{end}}" data-host="{{.Host}}" data-kind="{{if .ResourceKind}}{{.ResourceKind}}{{else}}javascript{{end}}">
+<td class="url{{if .ThirdParty}} third{{end}}"><a href="{{if .RawURL}}{{.RawURL}}{{else}}{{.JSURL}}{{end}}">{{.JSURL}}</a>{{if .Trigger}}<br><span class="muted">{{.Trigger}}</span>{{end}}</td>
+<td>{{.ResourceKind}}</td>
+<td>{{if eq .Observation "referenced-only"}}<span class="muted">ref</span>{{else}}{{.Status}}{{end}}</td>
+<td class="url">{{.SourcePage}}</td>
+<td class="url">{{.InitiatorURL}}{{if .InitiatorLine}}:{{.InitiatorLine}}{{end}}</td>
+<td>{{if .DecodedSize}}{{.DecodedSize}}{{end}}</td>
+<td>{{if .SourceMap}}<a href="{{.SourceMap}}">map</a>{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+</section>
+</main>
+<script>
+(function() {
+	var rows = Array.prototype.slice.call(document.querySelectorAll('#scripts tbody tr'));
+	var q = document.getElementById('q'), party = document.getElementById('party'),
+		host = document.getElementById('host'), kind = document.getElementById('kind'),
+		shown = document.getElementById('shown');
+	function apply() {
+		var text = q.value.toLowerCase(), n = 0;
+		rows.forEach(function(r) {
+			var ok = (!party.value || r.dataset.party === party.value) &&
+				(!host.value || r.dataset.host === host.value) &&
+				(!kind.value || r.dataset.kind === kind.value) &&
+				(!text || r.textContent.toLowerCase().indexOf(text) >= 0);
+			r.style.display = ok ? '' : 'none';
+			if (ok) n++;
+		});
+		shown.textContent = n + ' of ' + rows.length + ' shown';
+	}
+	[q, party, host, kind].forEach(function(el) { el.addEventListener('input', apply); });
+	apply();
+})();
+</script>
+</body>
+</html>`))
@@ -87,3 +87,53 @@ func HostInScope(u *url.URL, allowed []string) bool {
     return false
 }
 
+// JS URL normalization policies for NormalizeJSURL.
+const (
+    URLPolicyStrip     = "strip"     // drop the whole query string
+    URLPolicyCacheBust = "cachebust" // drop only known cache-busting parameters
+    URLPolicyKeep      = "keep"      // keep the query string as requested
+)
+
+// cacheBustParams are query parameters that only version a static asset.
+var cacheBustParams = map[string]struct{}{
+    "v": {}, "ver": {}, "version": {}, "_v": {}, "vsn": {}, "rev": {}, "build": {}, "release": {},
+    "t": {}, "_t": {}, "ts": {}, "time": {}, "timestamp": {}, "_": {},
+    "cb": {}, "cachebust": {}, "cachebuster": {}, "cache": {}, "bust": {}, "nocache": {},
+    "hash": {}, "h": {},
+}
+
+// NormalizeJSURL returns the key used to identify a script URL under the
+// given policy. Fragments are always dropped; an unknown or empty policy
+// behaves like URLPolicyStrip.
+func NormalizeJSURL(raw, policy string) string {
+    base := strings.SplitN(raw, "#", 2)[0]
+    path, query, hasQuery := strings.Cut(base, "?")
+    switch policy {
+    case URLPolicyKeep:
+        return base
+    case URLPolicyCacheBust:
+        if !hasQuery {
+            return path
+        }
+        kept := make([]string, 0, 4)
+        for _, kv := range strings.Split(query, "&") {
+            if kv == "" {
+                continue
+            }
+            k, _, _ := strings.Cut(kv, "=")
+            if uk, err := url.QueryUnescape(k); err == nil {
+                k = uk
+            }
+            if _, ok := cacheBustParams[strings.ToLower(k)]; ok {
+                continue
+            }
+            kept = append(kept, kv)
+        }
+        if len(kept) == 0 {
+            return path
+        }
+        return path + "?" + strings.Join(kept, "&")
+    default:
+        return path
+    }
+}
@@ -0,0 +1,26 @@
+package utils
+
+import "testing"
+
+func TestNormalizeJSURL(t *testing.T) {
+	cases := []struct {
+		raw, policy, want string
+	}{
+		{"https://a.com/app.js?v=1#x", URLPolicyStrip, "https://a.com/app.js"},
+		{"https://a.com/app.js?v=1", "", "https://a.com/app.js"},
+		{"https://a.com/app.js?v=1", "bogus", "https://a.com/app.js"},
+		{"https://a.com/app.js?v=1#x", URLPolicyKeep, "https://a.com/app.js?v=1"},
+		{"https://a.com/app.js", URLPolicyKeep, "https://a.com/app.js"},
+		{"https://a.com/app.js?v=1&_=99", URLPolicyCacheBust, "https://a.com/app.js"},
+		{"https://a.com/app.js?V=1&Cb=2", URLPolicyCacheBust, "https://a.com/app.js"},
+		{"https://a.com/app.js?%76=1", URLPolicyCacheBust, "https://a.com/app.js"},
+		{"https://a.com/loader.js?module=cart&v=3", URLPolicyCacheBust, "https://a.com/loader.js?module=cart"},
+		{"https://a.com/loader.js?a=1&&b=2&ts=5", URLPolicyCacheBust, "https://a.com/loader.js?a=1&b=2"},
+		{"https://a.com/app.js#v=1", URLPolicyCacheBust, "https://a.com/app.js"},
+	}
+	for _, c := range cases {
+		if got := NormalizeJSURL(c.raw, c.policy); got != c.want {
+			t.Errorf("NormalizeJSURL(%q, %q) = %q, want %q", c.raw, c.policy, got, c.want)
+		}
+	}
+}